@@ -0,0 +1,32 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+//Duration is a time.Duration that unmarshals from strings such as "5m" or "90s"
+type Duration struct {
+	time.Duration
+}
+
+//UnmarshalYAML parses the duration from its string form
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("Invalid duration %q: %v", s, err)
+	}
+
+	d.Duration = parsed
+	return nil
+}
+
+//MarshalYAML writes the duration in its string form
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import "sort"
+
+//Packing describes pods placed onto hypothetical nodes of a single size
+type Packing struct {
+	//Nodes holds the indexes of the pods placed onto each node, in the order the nodes were added
+	Nodes [][]int
+	//TooLarge holds the indexes of the pods that do not fit onto an empty node
+	TooLarge []int
+}
+
+//isExtended returns true for extended resources such as nvidia.com/gpu
+func isExtended(name string) bool {
+	switch name {
+	case ResourceCPU, ResourceMemory, ResourcePods, ResourceEphemeralStorage:
+		return false
+	}
+	return true
+}
+
+//packedNames returns the resources considered when packing. Resources the node has no capacity for are unknown and ignored,
+//except extended resources which the node can never provide
+func packedNames(pods []Resources, capacity *Resources) []string {
+	all := []*Resources{capacity}
+	for i := range pods {
+		all = append(all, &pods[i])
+	}
+
+	var names []string
+	for _, name := range Names(all...) {
+		if capacity.Get(name) > 0 || isExtended(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func fitsWithin(pod, free *Resources, names []string) bool {
+	for _, name := range names {
+		if pod.Get(name) > free.Get(name) {
+			return false
+		}
+	}
+	return true
+}
+
+//Pack places pods onto nodes with the given capacity using first fit decreasing.
+//Pods are ordered by the largest share of a node any of their resources takes
+func Pack(pods []Resources, capacity *Resources) *Packing {
+	names := packedNames(pods, capacity)
+	order := &byShare{
+		pods:   make([]int, len(pods)),
+		shares: make([]float64, len(pods)),
+	}
+	for i := range pods {
+		order.pods[i] = i
+		for _, name := range names {
+			if available := capacity.Get(name); available > 0 {
+				if share := float64(pods[i].Get(name)) / float64(available); share > order.shares[i] {
+					order.shares[i] = share
+				}
+			}
+		}
+	}
+	sort.Stable(order)
+
+	packing := &Packing{}
+	var free []*Resources
+	for _, i := range order.pods {
+		pod := &pods[i]
+		if !fitsWithin(pod, capacity, names) {
+			packing.TooLarge = append(packing.TooLarge, i)
+			continue
+		}
+
+		node := -1
+		for n := range free {
+			if fitsWithin(pod, free[n], names) {
+				node = n
+				break
+			}
+		}
+		if node < 0 {
+			node = len(free)
+			free = append(free, capacity.Copy())
+			packing.Nodes = append(packing.Nodes, nil)
+		}
+		free[node].Remove(pod)
+		packing.Nodes[node] = append(packing.Nodes[node], i)
+	}
+	return packing
+}
+
+//byShare orders pods by decreasing share of a node
+type byShare struct {
+	pods   []int
+	shares []float64
+}
+
+func (a *byShare) Len() int { return len(a.pods) }
+func (a *byShare) Swap(i, j int) {
+	a.pods[i], a.pods[j] = a.pods[j], a.pods[i]
+	a.shares[i], a.shares[j] = a.shares[j], a.shares[i]
+}
+func (a *byShare) Less(i, j int) bool { return a.shares[i] > a.shares[j] }
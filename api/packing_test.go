@@ -0,0 +1,70 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	node := &Resources{CPU: 4000, MemMB: 16000, Pods: 20}
+	cpu := func(millis int64) Resources { return Resources{CPU: millis, MemMB: 100, Pods: 1} }
+
+	tests := []struct {
+		pods     []Resources
+		nodes    int
+		tooLarge []int
+		test     string
+	}{
+		{
+			pods:  []Resources{cpu(3000), cpu(3000), cpu(3000), cpu(3000), cpu(3000)},
+			nodes: 5,
+			test:  "Pods Do Not Divide Evenly",
+		},
+		{
+			pods:  []Resources{cpu(1000), cpu(3000), cpu(1000), cpu(3000), cpu(2000), cpu(2000)},
+			nodes: 3,
+			test:  "Largest Pods Placed First",
+		},
+		{
+			pods:  []Resources{{CPU: 100, MemMB: 10000, Pods: 1}, {CPU: 100, MemMB: 10000, Pods: 1}, cpu(3900)},
+			nodes: 2,
+			test:  "Packed On Memory",
+		},
+		{
+			pods:  []Resources{{CPU: 10, MemMB: 10, Pods: 1}, {CPU: 10, MemMB: 10, Pods: 1}, {CPU: 10, MemMB: 10, Pods: 1}},
+			nodes: 1,
+			test:  "Small Pods Share A Node",
+		},
+		{
+			pods:     []Resources{cpu(5000), cpu(1000), *(&Resources{CPU: 100, Pods: 1}).Set(ResourceGPU, 1)},
+			nodes:    1,
+			tooLarge: []int{0, 2},
+			test:     "Too Large Reported Separately",
+		},
+		{
+			pods:  []Resources{{CPU: 100, Pods: 1, EphemeralStorageMB: 100000}},
+			nodes: 1,
+			test:  "Unknown Capacity Ignored",
+		},
+	}
+
+	for _, test := range tests {
+		packing := Pack(test.pods, node)
+		if len(packing.Nodes) != test.nodes {
+			t.Errorf("%s Failed. Expected %d nodes. Got %v", test.test, test.nodes, packing.Nodes)
+		}
+		if !reflect.DeepEqual(packing.TooLarge, test.tooLarge) {
+			t.Errorf("%s Failed. Expected too large %v. Got %v", test.test, test.tooLarge, packing.TooLarge)
+		}
+	}
+}
+
+func TestPackPodLimit(t *testing.T) {
+	pods := make([]Resources, 45)
+	for i := range pods {
+		pods[i] = Resources{CPU: 10, MemMB: 10, Pods: 1}
+	}
+	if packing := Pack(pods, &Resources{CPU: 4000, MemMB: 16000, Pods: 20}); len(packing.Nodes) != 3 {
+		t.Errorf("Expected 3 nodes limited by pods. Got %d", len(packing.Nodes))
+	}
+}
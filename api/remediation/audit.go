@@ -0,0 +1,48 @@
+package remediation
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/jmccarty3/awsScaler/api"
+)
+
+//AuditRecord describes a capacity change requested by a remediator
+type AuditRecord struct {
+	Time               time.Time     `json:"time"`
+	Strategy           string        `json:"strategy"`
+	Remediator         string        `json:"remediator"`
+	Group              string        `json:"group"`
+	OldDesiredCapacity int64         `json:"oldDesiredCapacity"`
+	NewDesiredCapacity int64         `json:"newDesiredCapacity"`
+	InstanceType       string        `json:"instanceType,omitempty"`
+	Pods               []string      `json:"pods"`
+	Needed             api.Resources `json:"needed"`
+	Error              string        `json:"error,omitempty"`
+}
+
+//AuditLog writes an AuditRecord per line as JSON
+type AuditLog struct {
+	w    io.Writer
+	lock sync.Mutex
+}
+
+//NewAuditLog creates an audit log writing to w
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w}
+}
+
+//Write appends the record to the log
+func (a *AuditLog) Write(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	_, err = a.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,39 @@
+package remediation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmccarty3/awsScaler/api"
+)
+
+func TestAudit(t *testing.T) {
+	var buf bytes.Buffer
+	req := &Request{Strategy: "alpha/workers", Pods: []string{"alpha/a"}, AuditLog: NewAuditLog(&buf)}
+
+	req.Audit(AuditRecord{Remediator: "test", Group: "one", OldDesiredCapacity: 1, NewDesiredCapacity: 3, Needed: api.Resources{CPU: 2000}}, nil)
+	req.Audit(AuditRecord{Remediator: "test", Group: "two", OldDesiredCapacity: 2, NewDesiredCapacity: 4}, errors.New("throttled"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records. Got %d: %s", len(lines), buf.String())
+	}
+
+	var first, second AuditRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("Unable to decode record: %v", err)
+	}
+	if first.Strategy != "alpha/workers" || first.Group != "one" || first.NewDesiredCapacity != 3 || first.Needed.CPU != 2000 ||
+		len(first.Pods) != 1 || first.Time.IsZero() || first.Error != "" {
+		t.Errorf("Unexpected record %v", first)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil || second.Error != "throttled" {
+		t.Errorf("Expected error in second record. Got %v %v", second, err)
+	}
+
+	//Requests without an audit log record nothing
+	(&Request{}).Audit(AuditRecord{Group: "one"}, nil)
+}
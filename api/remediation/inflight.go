@@ -0,0 +1,145 @@
+package remediation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jmccarty3/awsScaler/api"
+)
+
+//InFlight represents capacity requested from a group that has not yet joined the cluster
+type InFlight struct {
+	Group      string
+	Instances  int
+	Capacity   api.Resources
+	TargetSize int64
+	Pods       []string
+	Requested  time.Time
+}
+
+//InFlightLedger tracks requested capacity by group until the new nodes register or a timeout expires
+type InFlightLedger struct {
+	entries map[string][]*InFlight
+	timeout time.Duration
+
+	lock sync.Mutex
+}
+
+//NewInFlightLedger creates a ledger whose entries are considered failed after timeout
+func NewInFlightLedger(timeout time.Duration) *InFlightLedger {
+	return &InFlightLedger{
+		entries: make(map[string][]*InFlight),
+		timeout: timeout,
+	}
+}
+
+//Add records a new in flight scale up
+func (l *InFlightLedger) Add(entry *InFlight) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.entries[entry.Group] = append(l.entries[entry.Group], entry)
+}
+
+//Entries returns all in flight scale ups
+func (l *InFlightLedger) Entries() []*InFlight {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var entries []*InFlight
+	for _, e := range l.entries {
+		entries = append(entries, e...)
+	}
+	return entries
+}
+
+//PendingFor returns the in flight capacity requested for any of the given pods
+func (l *InFlightLedger) PendingFor(pods []string) *api.Resources {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	wanted := make(map[string]bool, len(pods))
+	for _, p := range pods {
+		wanted[p] = true
+	}
+
+	pending := api.Resources{}
+	for _, entries := range l.entries {
+		for _, e := range entries {
+			for _, p := range e.Pods {
+				if wanted[p] {
+					pending.Add(&e.Capacity)
+					break
+				}
+			}
+		}
+	}
+	return &pending
+}
+
+//HasPendingFor returns true if any in flight scale up was requested for one of the given pods
+func (l *InFlightLedger) HasPendingFor(pods []string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, entries := range l.entries {
+		for _, e := range entries {
+			for _, p := range e.Pods {
+				for _, wanted := range pods {
+					if p == wanted {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+//Release removes the group's entries that have been satisfied by the registered node count
+func (l *InFlightLedger) Release(group string, registered int) (released []*InFlight) {
+	return l.remove(func(e *InFlight) bool {
+		return e.Group == group && int64(registered) >= e.TargetSize
+	})
+}
+
+//ReleaseScheduled removes entries where none of the pods they were requested for are still pending
+func (l *InFlightLedger) ReleaseScheduled(isPending func(pod string) bool) (released []*InFlight) {
+	return l.remove(func(e *InFlight) bool {
+		for _, p := range e.Pods {
+			if isPending(p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+//Expire removes entries that have been in flight longer than the ledger timeout
+func (l *InFlightLedger) Expire(now time.Time) (failed []*InFlight) {
+	return l.remove(func(e *InFlight) bool {
+		return now.Sub(e.Requested) >= l.timeout
+	})
+}
+
+func (l *InFlightLedger) remove(matches func(e *InFlight) bool) (removed []*InFlight) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for group, entries := range l.entries {
+		remaining := entries[:0]
+		for _, e := range entries {
+			if matches(e) {
+				removed = append(removed, e)
+			} else {
+				remaining = append(remaining, e)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(l.entries, group)
+		} else {
+			l.entries[group] = remaining
+		}
+	}
+	return
+}
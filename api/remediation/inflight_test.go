@@ -0,0 +1,73 @@
+package remediation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmccarty3/awsScaler/api"
+)
+
+func TestInFlightPendingFor(t *testing.T) {
+	ledger := NewInFlightLedger(time.Minute)
+	ledger.Add(&InFlight{
+		Group:    "one",
+		Capacity: api.Resources{CPU: 1000, MemMB: 2000},
+		Pods:     []string{"ns/a", "ns/b"},
+	})
+	ledger.Add(&InFlight{
+		Group:    "two",
+		Capacity: api.Resources{CPU: 500, MemMB: 500},
+		Pods:     []string{"ns/c"},
+	})
+
+	tests := []struct {
+		pods     []string
+		expected api.Resources
+	}{
+		{
+			pods:     []string{"ns/a"},
+			expected: api.Resources{CPU: 1000, MemMB: 2000},
+		},
+		{
+			pods:     []string{"ns/a", "ns/b", "ns/c"},
+			expected: api.Resources{CPU: 1500, MemMB: 2500},
+		},
+		{
+			pods:     []string{"ns/d"},
+			expected: api.EmptyResources,
+		},
+	}
+
+	for _, test := range tests {
+		if actual := ledger.PendingFor(test.pods); !actual.Equal(&test.expected) {
+			t.Errorf("Pods: %v Expected: %v Actual: %v", test.pods, test.expected, *actual)
+		}
+		if ledger.HasPendingFor(test.pods) != !test.expected.IsEmpty() {
+			t.Errorf("Pods: %v unexpected HasPendingFor result", test.pods)
+		}
+	}
+}
+
+func TestInFlightRelease(t *testing.T) {
+	ledger := NewInFlightLedger(time.Minute)
+	ledger.Add(&InFlight{Group: "one", TargetSize: 5, Pods: []string{"ns/a"}, Requested: time.Now()})
+	ledger.Add(&InFlight{Group: "one", TargetSize: 7, Pods: []string{"ns/b"}, Requested: time.Now()})
+	ledger.Add(&InFlight{Group: "two", TargetSize: 2, Pods: []string{"ns/c"}, Requested: time.Now().Add(-2 * time.Minute)})
+
+	if released := ledger.Release("one", 5); len(released) != 1 || released[0].TargetSize != 5 {
+		t.Errorf("Expected only the first request to be released. Actual %v", released)
+	}
+
+	if failed := ledger.Expire(time.Now()); len(failed) != 1 || failed[0].Group != "two" {
+		t.Errorf("Expected group two to expire. Actual %v", failed)
+	}
+
+	released := ledger.ReleaseScheduled(func(pod string) bool { return pod != "ns/b" })
+	if len(released) != 1 {
+		t.Errorf("Expected scheduled request to be released. Actual %v", released)
+	}
+
+	if remaining := ledger.Entries(); len(remaining) != 0 {
+		t.Errorf("Expected empty ledger. Actual %v", remaining)
+	}
+}
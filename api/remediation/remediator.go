@@ -3,13 +3,54 @@ package remediation
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/jmccarty3/awsScaler/api"
 )
 
 //Remediator is responsible for taking action to resolve resource issues
 type Remediator interface {
-	Remediate(needed *api.Resources) (remainingNeeded *api.Resources, err error)
+	Remediate(needed *api.Resources, req *Request) (remainingNeeded *api.Resources, err error)
+}
+
+//Validator is implemented by remediators able to check their configuration after unmarshalling
+type Validator interface {
+	Validate() error
+}
+
+//GroupResolver is implemented by remediators able to report the groups they would currently scale
+type GroupResolver interface {
+	ResolveGroups() ([]string, error)
+}
+
+//GroupDescription describes the current state of a group a remediator scales
+type GroupDescription struct {
+	Name            string
+	DesiredCapacity int64
+	MaxSize         int64
+	Instances       int
+	//Activity describes the group's latest scaling activity. Empty if unknown
+	Activity string
+}
+
+//GroupDescriber is implemented by remediators able to describe the groups they would currently scale
+type GroupDescriber interface {
+	DescribeGroups() ([]GroupDescription, error)
+}
+
+//GroupCooldowns is implemented by remediators tracking when they last scaled each group.
+//Reloading the configuration carries the times over to the new remediators so cooldowns are kept
+type GroupCooldowns interface {
+	//LastScaled returns the last time each group was scaled
+	LastScaled() map[string]time.Time
+	//RestoreLastScaled records groups scaled before the remediator was created. Earlier times than those known are ignored
+	RestoreLastScaled(lastScaled map[string]time.Time)
+}
+
+//NamespaceRestricter is implemented by remediators able to limit the groups they scale to those a namespace may use.
+//Only such remediators are allowed in strategies owned by a namespace
+type NamespaceRestricter interface {
+	RestrictToNamespace(namespace string)
 }
 
 //ConfigData contains information required to configure a remediator
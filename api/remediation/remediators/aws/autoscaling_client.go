@@ -1,7 +1,10 @@
 package aws
 
 import (
+	"time"
+
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // AutoscalingClient performs operations of autoscaling.Autoscaling
@@ -12,3 +15,69 @@ type AutoscalingClient interface {
 	DescribeAutoScalingGroups(*autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error)
 	SetDesiredCapacity(input *autoscaling.SetDesiredCapacityInput) (*autoscaling.SetDesiredCapacityOutput, error)
 }
+
+var (
+	apiCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "aws_scaler",
+		Subsystem: "aws",
+		Name:      "api_call_duration_seconds",
+		Help:      "Latency of autoscaling API calls",
+	}, []string{"operation"})
+	apiCallErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "aws_scaler",
+		Subsystem: "aws",
+		Name:      "api_call_errors_total",
+		Help:      "Autoscaling API calls that returned an error",
+	}, []string{"operation"})
+)
+
+func init() {
+	prometheus.MustRegister(apiCallDuration, apiCallErrors)
+}
+
+//instrumentedClient records the latency and errors of calls made through an AutoscalingClient
+type instrumentedClient struct {
+	client AutoscalingClient
+}
+
+func observeAPICall(operation string, start time.Time, err error) {
+	apiCallDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		apiCallErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+func (c *instrumentedClient) DescribeScalingActivities(input *autoscaling.DescribeScalingActivitiesInput) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	start := time.Now()
+	output, err := c.client.DescribeScalingActivities(input)
+	observeAPICall("DescribeScalingActivities", start, err)
+	return output, err
+}
+
+func (c *instrumentedClient) DescribeLaunchConfigurations(input *autoscaling.DescribeLaunchConfigurationsInput) (*autoscaling.DescribeLaunchConfigurationsOutput, error) {
+	start := time.Now()
+	output, err := c.client.DescribeLaunchConfigurations(input)
+	observeAPICall("DescribeLaunchConfigurations", start, err)
+	return output, err
+}
+
+func (c *instrumentedClient) DescribeAutoScalingInstances(input *autoscaling.DescribeAutoScalingInstancesInput) (*autoscaling.DescribeAutoScalingInstancesOutput, error) {
+	start := time.Now()
+	output, err := c.client.DescribeAutoScalingInstances(input)
+	observeAPICall("DescribeAutoScalingInstances", start, err)
+	return output, err
+}
+
+func (c *instrumentedClient) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	start := time.Now()
+	output, err := c.client.DescribeAutoScalingGroups(input)
+	observeAPICall("DescribeAutoScalingGroups", start, err)
+	return output, err
+}
+
+func (c *instrumentedClient) SetDesiredCapacity(input *autoscaling.SetDesiredCapacityInput) (*autoscaling.SetDesiredCapacityOutput, error) {
+	start := time.Now()
+	output, err := c.client.SetDesiredCapacity(input)
+	observeAPICall("SetDesiredCapacity", start, err)
+	return output, err
+}
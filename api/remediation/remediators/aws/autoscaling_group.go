@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -18,6 +20,9 @@ import (
 //ScalerPriorityTagKey tag to use to attempt to order autoscaling groups
 const ScalerPriorityTagKey = "scaler_priority"
 
+//ScalerNamespacesTagKey tag listing the comma separated namespaces whose ScalingStrategies may scale a group
+const ScalerNamespacesTagKey = "scaler_namespaces"
+
 //RemediatorName name to use when registering the remediator
 const RemediatorName = "autoScalingGroup"
 
@@ -25,24 +30,78 @@ const RemediatorName = "autoScalingGroup"
 type ASGConfig struct {
 	Names                      []string `yaml:"names"`
 	Tags                       map[string]string
-	SelfTags                   []string `yaml:"selfTags"`
-	MaxMachineIncrement        *int     `yaml:"maxMachineIncrement"`
-	StopIfMaximallyIncremented bool     `yaml:"stopIfMaximallyIncremented"`
+	SelfTags                   []string      `yaml:"selfTags"`
+	MaxMachineIncrement        *int          `yaml:"maxMachineIncrement"`
+	StopIfMaximallyIncremented bool          `yaml:"stopIfMaximallyIncremented"`
+	Cooldown                   *api.Duration `yaml:"cooldown"`
+	HonorCooldown              bool          `yaml:"honorCooldown"`
+	//KubeReserved and SystemReserved are subtracted from the capacity of every new instance like the kubelet flags of the same name
+	KubeReserved   *ReservedConfig `yaml:"kubeReserved"`
+	SystemReserved *ReservedConfig `yaml:"systemReserved"`
+}
+
+//ReservedConfig describes capacity of an instance not available to pods
+type ReservedConfig struct {
+	//CPU is in millicores
+	CPU                int64 `yaml:"cpu"`
+	MemoryMB           int64 `yaml:"memoryMB"`
+	EphemeralStorageMB int64 `yaml:"ephemeralStorageMB"`
+}
+
+func (r *ReservedConfig) resources() *api.Resources {
+	if r == nil {
+		return &api.Resources{}
+	}
+	return &api.Resources{
+		CPU:                r.CPU,
+		MemMB:              r.MemoryMB,
+		EphemeralStorageMB: r.EphemeralStorageMB,
+	}
+}
+
+func (r *ReservedConfig) validate(field string) error {
+	if r != nil && (r.CPU < 0 || r.MemoryMB < 0 || r.EphemeralStorageMB < 0) {
+		return fmt.Errorf("%s: Must not be negative", field)
+	}
+	return nil
 }
 
 //ASGRemediator attempts to remediate scheduling issues using AutoScalingGroups
 type ASGRemediator struct {
 	ASGConfig
-	client AutoscalingClient
+	//client is created on first use so configuration can be loaded without AWS access
+	client     AutoscalingClient
+	clientLock sync.Mutex
+	//lastScaled holds the last time each group was scaled by the remediator
+	lastScaled map[string]time.Time
+	//namespace limits the remediator to groups whose ScalerNamespacesTagKey tag lists it. Empty allows any group
+	namespace string
 }
 
 func newASGRemediator(config rem.ConfigData) rem.Remediator {
 	return &ASGRemediator{
-		client: autoscaling.New(session.New(&aws.Config{
+		lastScaled: make(map[string]time.Time),
+	}
+}
+
+func (asgRemediator *ASGRemediator) getClient() AutoscalingClient {
+	asgRemediator.clientLock.Lock()
+	defer asgRemediator.clientLock.Unlock()
+
+	if asgRemediator.client == nil {
+		asgRemediator.client = &instrumentedClient{client: autoscaling.New(session.New(&aws.Config{
 			Credentials: getAWSCredentials(),
 			Region:      aws.String(getRegion()),
-		})),
+		}))}
 	}
+	return asgRemediator.client
+}
+
+//SetClient replaces the client used to describe and scale autoscaling groups
+func (asgRemediator *ASGRemediator) SetClient(client AutoscalingClient) {
+	asgRemediator.clientLock.Lock()
+	defer asgRemediator.clientLock.Unlock()
+	asgRemediator.client = client
 }
 
 //UnmarshalYAML is used to unmarshal the remediator from yaml config
@@ -53,6 +112,35 @@ func (asgRemediator *ASGRemediator) UnmarshalYAML(unmarshal func(interface{}) er
 	return err
 }
 
+//RestrictToNamespace limits the remediator to groups tagged as usable by the namespace regardless of the names and tags configured
+func (asgRemediator *ASGRemediator) RestrictToNamespace(namespace string) {
+	asgRemediator.namespace = namespace
+}
+
+//Validate ensures the remediator can locate groups and has sensible limits
+func (asgRemediator *ASGRemediator) Validate() error {
+	if len(asgRemediator.Names) == 0 && len(asgRemediator.Tags) == 0 && len(asgRemediator.SelfTags) == 0 {
+		return errors.New("At least one of names, tags or selfTags is required")
+	}
+
+	if asgRemediator.MaxMachineIncrement != nil && *asgRemediator.MaxMachineIncrement < 0 {
+		return fmt.Errorf("maxMachineIncrement: Must not be negative. Got %d", *asgRemediator.MaxMachineIncrement)
+	}
+
+	if asgRemediator.Cooldown != nil && asgRemediator.Cooldown.Duration < 0 {
+		return fmt.Errorf("cooldown: Must not be negative. Got %v", asgRemediator.Cooldown.Duration)
+	}
+
+	if err := asgRemediator.KubeReserved.validate("kubeReserved"); err != nil {
+		return err
+	}
+	if err := asgRemediator.SystemReserved.validate("systemReserved"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func init() {
 	rem.RegisterRemediator(RemediatorName, newASGRemediator)
 }
@@ -67,7 +155,7 @@ func mergeTags(primary, secondary map[string]string) map[string]string {
 }
 
 //Remediate will attempt to increase autoscaling groups to resolve the failed pods
-func (asgRemediator *ASGRemediator) Remediate(needed *api.Resources) (remainingNeeded *api.Resources, err error) {
+func (asgRemediator *ASGRemediator) Remediate(needed *api.Resources, req *rem.Request) (remainingNeeded *api.Resources, err error) {
 	remainingNeeded = needed
 
 	tags := asgRemediator.Tags
@@ -89,9 +177,22 @@ func (asgRemediator *ASGRemediator) Remediate(needed *api.Resources) (remainingN
 	groups = sortAutoScalingGroups(groups)
 
 	for _, group := range groups {
+		if req.Stopped() {
+			return remainingNeeded, errors.New("Remediation cancelled")
+		}
+
+		for _, released := range req.ReleaseRegistered(*group.AutoScalingGroupName, instanceIDs(group)) {
+			glog.Infof("%d instances requested from group %s at %v have registered", released.Instances, released.Group, released.Requested)
+		}
+
+		if cooling, until := asgRemediator.inCooldown(*group.AutoScalingGroupName, time.Now()); cooling {
+			glog.Infof("Group %s in cooldown until %v. Skipping", *group.AutoScalingGroupName, until)
+			continue
+		}
+
 		glog.Info("Attempting to Remediate using group: ", *group.AutoScalingGroupName)
-		if remainingNeeded, err = asgRemediator.attemptRemediate(group, remainingNeeded); err == nil {
-			if *remainingNeeded != api.EmptyResources {
+		if remainingNeeded, err = asgRemediator.attemptRemediate(group, remainingNeeded, req); err == nil {
+			if !remainingNeeded.IsEmpty() {
 				glog.Infof("Autoscaling group %s did not fully meet resource need. NeededResources %v", group, remainingNeeded)
 				continue
 			}
@@ -104,33 +205,110 @@ func (asgRemediator *ASGRemediator) Remediate(needed *api.Resources) (remainingN
 	return remainingNeeded, err
 }
 
-//TODO condense br returning error and having the calling function panic
-func (asgRemediator *ASGRemediator) getSelfTags() (tags map[string]string) {
+//resolveGroups returns the groups the remediator currently matches in the order they are tried
+func (asgRemediator *ASGRemediator) resolveGroups() ([]*autoscaling.Group, error) {
+	tags := make(map[string]string)
+	if len(asgRemediator.SelfTags) != 0 {
+		var err error
+		if tags, err = asgRemediator.lookupSelfTags(); err != nil {
+			return nil, err
+		}
+	}
+	tags = mergeTags(asgRemediator.Tags, tags)
+
+	groups, err := asgRemediator.getAllAutoscalingGroups(&asgRemediator.Names, &tags)
+	if err != nil {
+		return nil, err
+	}
+	return sortAutoScalingGroups(groups), nil
+}
+
+//ResolveGroups returns the names of the groups the remediator currently matches in the order they are tried
+func (asgRemediator *ASGRemediator) ResolveGroups() ([]string, error) {
+	groups, err := asgRemediator.resolveGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(groups))
+	for i, group := range groups {
+		names[i] = *group.AutoScalingGroupName
+	}
+	return names, nil
+}
+
+//DescribeGroups describes the sizes and latest scaling activity of the groups the remediator currently matches
+func (asgRemediator *ASGRemediator) DescribeGroups() ([]rem.GroupDescription, error) {
+	groups, err := asgRemediator.resolveGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := make([]rem.GroupDescription, len(groups))
+	for i, group := range groups {
+		descriptions[i] = rem.GroupDescription{
+			Name:            *group.AutoScalingGroupName,
+			DesiredCapacity: *group.DesiredCapacity,
+			MaxSize:         *group.MaxSize,
+			Instances:       len(group.Instances),
+		}
+		if activity, err := asgRemediator.getCurrentActivity(*group.AutoScalingGroupName); err == nil {
+			descriptions[i].Activity = describeActivity(activity)
+		}
+	}
+	return descriptions, nil
+}
+
+//describeActivity summarizes a scaling activity
+func describeActivity(activity *autoscaling.Activity) string {
+	description := *activity.StatusCode
+	if activity.Description != nil {
+		description += ": " + *activity.Description
+	}
+	if activity.Progress != nil && *activity.StatusCode == autoscaling.ScalingActivityStatusCodeInProgress {
+		description += fmt.Sprintf(" (%d%%)", *activity.Progress)
+	}
+	return description
+}
+
+func (asgRemediator *ASGRemediator) getSelfTags() map[string]string {
+	tags, err := asgRemediator.lookupSelfTags()
+	if err != nil {
+		panic(err.Error())
+	}
+	return tags
+}
+
+//lookupSelfTags finds the values of the SelfTags on the group the scaler is running in
+func (asgRemediator *ASGRemediator) lookupSelfTags() (tags map[string]string, err error) {
 	metaData := getMetadataClient()
 
 	if !metaData.Available() {
-		panic("Metadata service not available. Possibly not running in AWS. Please check configuration")
+		return nil, errors.New("Metadata service not available. Possibly not running in AWS. Please check configuration")
 	}
 	var instanceID string
 	if doc, err := metaData.GetInstanceIdentityDocument(); err != nil {
-		panic(fmt.Sprintf("Unable to fetch instance id. %v", err))
+		return nil, fmt.Errorf("Unable to fetch instance id. %v", err)
 	} else {
 		instanceID = doc.InstanceID
 	}
 
-	output, err := asgRemediator.client.DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+	output, err := asgRemediator.getClient().DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
 		InstanceIds: []*string{aws.String(instanceID)},
 	})
 
 	if err != nil {
-		panic(fmt.Sprintf("Unable to describe autoscaling instances. Error: %v", err))
+		return nil, fmt.Errorf("Unable to describe autoscaling instances. Error: %v", err)
 	}
 
 	if len(output.AutoScalingInstances) != 1 {
-		panic("Incorrect number of autoscaling groups for self")
+		return nil, errors.New("Incorrect number of autoscaling groups for self")
 	}
 
-	group, _ := asgRemediator.getAutoscalingGroup(*output.AutoScalingInstances[0].AutoScalingGroupName)
+	group, err := asgRemediator.getAutoscalingGroup(*output.AutoScalingInstances[0].AutoScalingGroupName)
+	if err != nil {
+		return nil, err
+	}
 	tags = make(map[string]string)
 	for _, tag := range group.Tags {
 		if stringSliceContains(asgRemediator.SelfTags, *tag.Key) {
@@ -139,14 +317,14 @@ func (asgRemediator *ASGRemediator) getSelfTags() (tags map[string]string) {
 	}
 
 	if len(tags) != len(asgRemediator.SelfTags) {
-		panic("Not all self tags found")
+		return nil, errors.New("Not all self tags found")
 	}
-	return
+	return tags, nil
 }
 
 func (asgRemediator *ASGRemediator) getAllAutoscalingGroups(names *[]string, tags *map[string]string) ([]*autoscaling.Group, error) {
 	glog.Info("Fetching all autoscaling groups")
-	resp, err := asgRemediator.client.DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{})
+	resp, err := asgRemediator.getClient().DescribeAutoScalingGroups(&autoscaling.DescribeAutoScalingGroupsInput{})
 
 	if err != nil {
 		glog.Errorf("Error fetching autoscaling groups. %v", err)
@@ -156,6 +334,11 @@ func (asgRemediator *ASGRemediator) getAllAutoscalingGroups(names *[]string, tag
 	groups := []*autoscaling.Group{}
 
 	for _, asg := range resp.AutoScalingGroups {
+		if asgRemediator.namespace != "" && !allowsNamespace(asg.Tags, asgRemediator.namespace) {
+			glog.V(4).Infof("Skipping autoscaling group %s. Its %s tag does not allow namespace %s", *asg.AutoScalingGroupName, ScalerNamespacesTagKey, asgRemediator.namespace)
+			continue
+		}
+
 		if stringSliceContains(*names, *asg.AutoScalingGroupName) {
 			glog.Infof("Found matching autoscaling group name %s", *asg.AutoScalingGroupName)
 			groups = append(groups, asg)
@@ -221,6 +404,20 @@ func allTagsPresent(toSearch []*autoscaling.TagDescription, toFind map[string]st
 	return foundCount == len(toFind)
 }
 
+//allowsNamespace returns true if the group's ScalerNamespacesTagKey tag lists the namespace
+func allowsNamespace(tags []*autoscaling.TagDescription, namespace string) bool {
+	for _, t := range tags {
+		if *t.Key == ScalerNamespacesTagKey {
+			for _, allowed := range strings.Split(*t.Value, ",") {
+				if strings.TrimSpace(allowed) == namespace {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (asgRemediator *ASGRemediator) isGroupValid(group *autoscaling.Group) bool {
 	if stringSliceContains(asgRemediator.Names, *group.AutoScalingGroupName) {
 		return true
@@ -237,7 +434,7 @@ func (asgRemediator *ASGRemediator) getAutoscalingGroup(asGroup string) (*autosc
 		MaxRecords: aws.Int64(1),
 	}
 
-	resp, err := asgRemediator.client.DescribeAutoScalingGroups(params)
+	resp, err := asgRemediator.getClient().DescribeAutoScalingGroups(params)
 
 	if err != nil {
 		glog.Error("Error fetching Autoscaling group:", asGroup, " Error:", err)
@@ -252,8 +449,18 @@ func (asgRemediator *ASGRemediator) getAutoscalingGroup(asGroup string) (*autosc
 	return resp.AutoScalingGroups[0], nil
 }
 
-func (asgRemediator *ASGRemediator) attemptRemediate(asGroup *autoscaling.Group, neededResources *api.Resources) (remainingNeededResources *api.Resources, err error) {
-	if *asGroup.DesiredCapacity >= *asGroup.MaxSize {
+//groupSize returns the size new instances are added to. Instances may still be launching for an earlier request,
+//or outnumber the desired capacity while terminating, so the larger of the two is used
+func groupSize(asGroup *autoscaling.Group) int {
+	size := int(*asGroup.DesiredCapacity)
+	if len(asGroup.Instances) > size {
+		size = len(asGroup.Instances)
+	}
+	return size
+}
+
+func (asgRemediator *ASGRemediator) attemptRemediate(asGroup *autoscaling.Group, neededResources *api.Resources, req *rem.Request) (remainingNeededResources *api.Resources, err error) {
+	if int64(groupSize(asGroup)) >= *asGroup.MaxSize {
 		glog.Warning("Autoscaling group already at max size")
 		return neededResources, fmt.Errorf("Failed to scale.  Autoscaling group %s at max size.", asGroup.String())
 	}
@@ -276,7 +483,11 @@ func (asgRemediator *ASGRemediator) attemptRemediate(asGroup *autoscaling.Group,
 			spotTimeout := 2 //TODO Make configurable if stays
 			glog.Info("Autoscaling group is cluster waiting on spot work. Giving ", spotTimeout, " for instance increase")
 			initialCount := len(asGroup.Instances)
-			time.Sleep(time.Duration(spotTimeout) * time.Minute)
+			select {
+			case <-time.After(time.Duration(spotTimeout) * time.Minute):
+			case <-req.Done():
+				return neededResources, errors.New("Remediation cancelled while waiting on spot instances")
+			}
 			asGroup, err = asgRemediator.getAutoscalingGroup(*asGroup.AutoScalingGroupName)
 			if err != nil {
 				glog.Warning("Error obtaining autoscaling group:", err)
@@ -290,8 +501,47 @@ func (asgRemediator *ASGRemediator) attemptRemediate(asGroup *autoscaling.Group,
 	}
 
 	//Determine how many servers we should
-	launchConfig, _ := getLaunchConfig(asgRemediator.client, *asGroup.LaunchConfigurationName)
-	neededCount, resourcePerMachine := calculatedNeededServersForConfig(launchConfig, neededResources)
+	if asGroup.LaunchConfigurationName == nil {
+		return neededResources, fmt.Errorf("Group %s has no launch configuration. Groups using launch templates are not supported", *asGroup.AutoScalingGroupName)
+	}
+	launchConfig, err := getLaunchConfig(asgRemediator.getClient(), *asGroup.LaunchConfigurationName)
+	if err != nil {
+		return neededResources, errors.Wrapf(err, "Unable to get the launch configuration of group %s", *asGroup.AutoScalingGroupName)
+	}
+	if launchConfig.InstanceType == nil {
+		return neededResources, fmt.Errorf("Launch configuration %s of group %s has no instance type", *asGroup.LaunchConfigurationName, *asGroup.AutoScalingGroupName)
+	}
+	capacity, err := asgRemediator.nodeCapacity(asGroup, launchConfig, req)
+	if err != nil {
+		return neededResources, err
+	}
+	var neededCount int
+	var resourcePerMachine api.Resources
+	//Pack individual pods when they are known. Summed requests under count instances when pods do not divide evenly onto them
+	demands := req.PendingDemands()
+	var packing *api.Packing
+	if len(demands) > 0 {
+		packing = packDemands(capacity, demands)
+	}
+	if packing != nil {
+		neededCount, resourcePerMachine = len(packing.Nodes), *capacity.Copy()
+		if len(packing.TooLarge) > 0 {
+			tooLarge := rem.TooLarge{Group: *asGroup.AutoScalingGroupName, InstanceType: *launchConfig.InstanceType}
+			for _, i := range packing.TooLarge {
+				tooLarge.Pods = append(tooLarge.Pods, demands[i].Pod)
+			}
+			glog.Warningf("Pods too large for instance type %s of group %s: %v", tooLarge.InstanceType, tooLarge.Group, tooLarge.Pods)
+			req.RecordTooLarge(tooLarge)
+		}
+		if neededCount == 0 {
+			return neededResources, fmt.Errorf("No pending pod fits on instance type %s of group %s", *launchConfig.InstanceType, *asGroup.AutoScalingGroupName)
+		}
+	} else {
+		neededCount, resourcePerMachine, err = calculatedNeededServersForConfig(launchConfig, capacity, neededResources)
+		if err != nil {
+			return neededResources, errors.Wrapf(err, "Unable to remediate with group %s", *asGroup.AutoScalingGroupName)
+		}
+	}
 	glog.Infof("Need %v servers from group %s", neededCount, *asGroup.AutoScalingGroupName)
 
 	requestingMaxMachineIncrement := asgRemediator.MaxMachineIncrement != nil && neededCount >= *asgRemediator.MaxMachineIncrement
@@ -299,47 +549,142 @@ func (asgRemediator *ASGRemediator) attemptRemediate(asGroup *autoscaling.Group,
 		glog.Infof("MaxMachineIncrement exceeds needed number of servers for group %s.  Resetting needed servers from %v to %v ", *asGroup.AutoScalingGroupName, neededCount, *asgRemediator.MaxMachineIncrement)
 		neededCount = *asgRemediator.MaxMachineIncrement
 	}
-	sizeToScaleTo := len(asGroup.Instances) + neededCount
+	currentSize := groupSize(asGroup)
+	sizeToScaleTo := currentSize + neededCount
 	if int64(sizeToScaleTo) > *asGroup.MaxSize {
 		glog.Info("Desired capacity too large. Setting to Max.")
 		sizeToScaleTo = int(*asGroup.MaxSize)
-		neededCount = sizeToScaleTo - len(asGroup.Instances)
+		neededCount = sizeToScaleTo - currentSize
+	}
+	//The group may have grown while waiting on spot instances
+	if neededCount <= 0 {
+		return neededResources, fmt.Errorf("Failed to scale.  Autoscaling group %s at max size.", asGroup.String())
 	}
 
-	glog.Info("Requesting group capacity increase for:", *asGroup.AutoScalingGroupName)
-	err = asgRemediator.scaleGroup(*asGroup.AutoScalingGroupName, int64(sizeToScaleTo))
-	if err != nil {
-		return neededResources, errors.Wrapf(err, "Error scaling group %s", asGroup.String())
+	//Only the pods placed onto the instances requested are scaled for. The rest are still pending next cycle
+	var placed []string
+	if packing != nil {
+		placed = placedPods(demands, packing, neededCount)
 	}
 
-	resourcesAdded := resourcePerMachine.Scale(int64(neededCount))
+	if req.IsDryRun() {
+		glog.Infof("Dry run. Would set group %s capacity from %d to %d", *asGroup.AutoScalingGroupName, currentSize, sizeToScaleTo)
+	} else {
+		glog.Info("Requesting group capacity increase for:", *asGroup.AutoScalingGroupName)
+		err = asgRemediator.scaleGroup(*asGroup.AutoScalingGroupName, int64(sizeToScaleTo))
+		record := rem.AuditRecord{
+			Remediator:         RemediatorName,
+			Group:              *asGroup.AutoScalingGroupName,
+			OldDesiredCapacity: *asGroup.DesiredCapacity,
+			NewDesiredCapacity: int64(sizeToScaleTo),
+			InstanceType:       *launchConfig.InstanceType,
+			Pods:               placed,
+			Needed:             *neededResources.Copy(),
+		}
+		req.Audit(record, err)
+		if err != nil {
+			return neededResources, errors.Wrapf(err, "Error scaling group %s", asGroup.String())
+		}
+	}
 
-	if *resourcesAdded == api.EmptyResources {
-		glog.Warning("Unable to determine now many resources were created. Optimistically assuming everything is fixed")
-		return &api.EmptyResources, nil
+	resourcesAdded := resourcePerMachine.Scale(int64(neededCount))
+	scaleUp := rem.ScaleUp{
+		Group:     *asGroup.AutoScalingGroupName,
+		FromSize:  int64(currentSize),
+		ToSize:    int64(sizeToScaleTo),
+		Instances: neededCount,
+		Capacity:  *resourcesAdded.Copy(),
+		Pods:      placed,
 	}
+	req.RecordScaleUp(scaleUp)
 
 	if requestingMaxMachineIncrement && asgRemediator.StopIfMaximallyIncremented {
 		return &api.EmptyResources, nil
 	}
+	if packing != nil {
+		return req.SetPendingDemands(unplacedDemands(demands, packing, neededCount)), nil
+	}
 	return neededResources.Remove(resourcesAdded), nil
 }
 
+//nodeCapacity returns the capacity pods can use on a new instance of the group less the requests of the DaemonSet pods that will run on it.
+//The allocatable resources of a registered node of the group are used when known as they already account for the AMI and kubelet
+//reservations. Otherwise the capacity of the instance type less the configured reservations is used
+func (asgRemediator *ASGRemediator) nodeCapacity(asGroup *autoscaling.Group, config *autoscaling.LaunchConfiguration, req *rem.Request) (api.Resources, error) {
+	overhead := &api.Resources{}
+	var capacity api.Resources
+	if observed, exists := req.ObservedCapacity(*asGroup.AutoScalingGroupName, *config.InstanceType); exists {
+		capacity = *observed
+		glog.V(2).Infof("Using allocatable resources %v of a registered node of group %s", capacity, *asGroup.AutoScalingGroupName)
+	} else {
+		capacity = getResourceForInstanceType(config.InstanceType)
+		if capacity.IsEmpty() {
+			unknownInstanceTypes.WithLabelValues(*config.InstanceType).Inc()
+			return capacity, fmt.Errorf("Instance type %s of group %s is not in the instance catalog. Add it to the instanceCatalog file", *config.InstanceType, *asGroup.AutoScalingGroupName)
+		}
+		overhead.Add(asgRemediator.KubeReserved.resources())
+		overhead.Add(asgRemediator.SystemReserved.resources())
+	}
+	overhead.Add(req.DaemonSetOverhead(instanceIDs(asGroup)))
+	for _, name := range api.Names(&capacity) {
+		if available := capacity.Get(name); available > 0 && overhead.Get(name) >= available {
+			return api.EmptyResources, fmt.Errorf("Reservations and DaemonSets use all %s of instance type %s in group %s. Overhead: %v", name, *config.InstanceType, *asGroup.AutoScalingGroupName, overhead)
+		}
+	}
+
+	capacity.Remove(overhead)
+	glog.V(2).Infof("New instances of group %s provide %v after overhead %v", *asGroup.AutoScalingGroupName, capacity, overhead)
+	return capacity, nil
+}
+
+//inCooldown returns true and the time the cooldown ends if the group was recently scaled by the remediator
+func (asgRemediator *ASGRemediator) inCooldown(name string, now time.Time) (bool, time.Time) {
+	if asgRemediator.Cooldown == nil {
+		return false, now
+	}
+
+	until := asgRemediator.lastScaled[name].Add(asgRemediator.Cooldown.Duration)
+	return now.Before(until), until
+}
+
+//LastScaled returns the last time each group was scaled by the remediator
+func (asgRemediator *ASGRemediator) LastScaled() map[string]time.Time {
+	return asgRemediator.lastScaled
+}
+
+//RestoreLastScaled records groups scaled before the remediator was created, such as by the configuration it replaces
+func (asgRemediator *ASGRemediator) RestoreLastScaled(lastScaled map[string]time.Time) {
+	if asgRemediator.lastScaled == nil {
+		asgRemediator.lastScaled = make(map[string]time.Time)
+	}
+	for name, scaled := range lastScaled {
+		if scaled.After(asgRemediator.lastScaled[name]) {
+			asgRemediator.lastScaled[name] = scaled
+		}
+	}
+}
+
 func (asgRemediator *ASGRemediator) scaleGroup(name string, size int64) error {
 	params := &autoscaling.SetDesiredCapacityInput{
 		AutoScalingGroupName: aws.String(name),
 		DesiredCapacity:      aws.Int64(size),
-		HonorCooldown:        aws.Bool(false), //TODO Make this settable
+		HonorCooldown:        aws.Bool(asgRemediator.HonorCooldown),
 	}
 
-	_, err := asgRemediator.client.SetDesiredCapacity(params)
+	_, err := asgRemediator.getClient().SetDesiredCapacity(params)
 	glog.Infof("Requested AS Group %s be set to capacity %v", name, size)
+	if err == nil {
+		if asgRemediator.lastScaled == nil {
+			asgRemediator.lastScaled = make(map[string]time.Time)
+		}
+		asgRemediator.lastScaled[name] = time.Now()
+	}
 
 	return err
 }
 
 func (asgRemediator *ASGRemediator) groupIsSpotCluster(clusterName string) (bool, error) {
-	config, err := getLaunchConfig(asgRemediator.client, clusterName)
+	config, err := getLaunchConfig(asgRemediator.getClient(), clusterName)
 
 	if err != nil {
 		return false, err
@@ -358,7 +703,7 @@ func (asgRemediator *ASGRemediator) getCurrentActivity(asgName string) (*autosca
 		MaxRecords:           aws.Int64(1), //Only want the last/current action
 	}
 
-	resp, err := asgRemediator.client.DescribeScalingActivities(params)
+	resp, err := asgRemediator.getClient().DescribeScalingActivities(params)
 
 	if err != nil {
 		return nil, err
@@ -2,17 +2,21 @@ package aws
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/mock/gomock"
 	"github.com/jmccarty3/awsScaler/api"
+	rem "github.com/jmccarty3/awsScaler/api/remediation"
 
 	"gopkg.in/yaml.v2"
 )
@@ -252,8 +256,11 @@ func TestAttemptRemediate(t *testing.T) {
 		err                                error
 	}
 	name := aws.String("blah")
-	tests := map[inputs]expectedResults{
-		inputs{ // Request 6 additional machines; limit 5 by MaxMachineIncrement;
+	tests := []struct {
+		in  inputs
+		out expectedResults
+	}{
+		{inputs{ // Request 6 additional machines; limit 5 by MaxMachineIncrement;
 			configMaxMachineIncrement: aws.Int(5),
 			configStopIfMaxIncrement:  false,
 			asgDesiredCapactiy:        5,
@@ -265,15 +272,15 @@ func TestAttemptRemediate(t *testing.T) {
 				CPU:   96000, // 6x instanceType's CPU
 				MemMB: 64000,
 			},
-		}: expectedResults{
+		}, expectedResults{
 			shouldDescribeScalingActivities:    true,
 			shouldDescribeLaunchConfigurations: true,
 			shouldSetDesiredCapacity:           true,
 			setDesiredCapacity:                 10,
 			remainingNeededResources:           api.Resources{CPU: 16000}, // failed to get 1 ec2 instance's worth of CPU
-		},
+		}},
 
-		inputs{ // Request 6 additional machines; limit 5 by MaxMachineIncrement; return empty resources due to StopIfMaximallyIncremented
+		{inputs{ // Request 6 additional machines; limit 5 by MaxMachineIncrement; return empty resources due to StopIfMaximallyIncremented
 			configMaxMachineIncrement: aws.Int(5),
 			configStopIfMaxIncrement:  true,
 			asgDesiredCapactiy:        5,
@@ -285,15 +292,15 @@ func TestAttemptRemediate(t *testing.T) {
 				CPU:   96000, // 6x instanceType's CPU
 				MemMB: 64000,
 			},
-		}: expectedResults{
+		}, expectedResults{
 			shouldDescribeScalingActivities:    true,
 			shouldDescribeLaunchConfigurations: true,
 			shouldSetDesiredCapacity:           true,
 			setDesiredCapacity:                 10,
 			remainingNeededResources:           api.EmptyResources,
-		},
+		}},
 
-		inputs{ // limited by asgMaxSize
+		{inputs{ // limited by asgMaxSize
 			configStopIfMaxIncrement: true,
 			asgDesiredCapactiy:       5,
 			asgMaxSize:               10,
@@ -304,15 +311,15 @@ func TestAttemptRemediate(t *testing.T) {
 				CPU:   96000, // 6x instanceType's CPU
 				MemMB: 100000,
 			},
-		}: expectedResults{
+		}, expectedResults{
 			shouldDescribeScalingActivities:    true,
 			shouldDescribeLaunchConfigurations: true,
 			shouldSetDesiredCapacity:           true,
 			setDesiredCapacity:                 10,
 			remainingNeededResources:           api.Resources{CPU: 16000},
-		},
+		}},
 
-		inputs{ // resources not limited; should add 6
+		{inputs{ // resources not limited; should add 6
 			asgDesiredCapactiy:     5,
 			asgMaxSize:             15,
 			asgCurrentNumInstances: 5,
@@ -322,42 +329,74 @@ func TestAttemptRemediate(t *testing.T) {
 				CPU:   96000, // 6x instanceType's CPU
 				MemMB: 10,
 			},
-		}: expectedResults{
+		}, expectedResults{
 			shouldDescribeScalingActivities:    true,
 			shouldDescribeLaunchConfigurations: true,
 			shouldSetDesiredCapacity:           true,
 			setDesiredCapacity:                 11,
 			remainingNeededResources:           api.EmptyResources,
-		},
+		}},
+
+		{inputs{ // instances still launching; scale relative to desired capacity
+			asgDesiredCapactiy:     8,
+			asgMaxSize:             15,
+			asgCurrentNumInstances: 5,
+			activityStatusCode:     autoscaling.ScalingActivityStatusCodeSuccessful,
+			instanceType:           ec2.InstanceTypeM44xlarge,
+			neededResources: api.Resources{
+				CPU:   96000, // 6x instanceType's CPU
+				MemMB: 10,
+			},
+		}, expectedResults{
+			shouldDescribeScalingActivities:    true,
+			shouldDescribeLaunchConfigurations: true,
+			shouldSetDesiredCapacity:           true,
+			setDesiredCapacity:                 14,
+			remainingNeededResources:           api.EmptyResources,
+		}},
 
 		// initial desired exceeds max size -> error
-		inputs{
+		{inputs{
 			asgDesiredCapactiy:     16,
 			asgMaxSize:             15,
 			asgCurrentNumInstances: 5,
 			activityStatusCode:     autoscaling.ScalingActivityStatusCodeSuccessful,
 			instanceType:           ec2.InstanceTypeM44xlarge,
 			neededResources:        api.Resources{CPU: 96000, MemMB: 10},
-		}: expectedResults{
+		}, expectedResults{
 			shouldDescribeScalingActivities: false,
 			remainingNeededResources:        api.Resources{CPU: 96000, MemMB: 10},
 			err: fmt.Errorf("Failed to scale.  Autoscaling group blah at max size."),
-		},
+		}},
+
+		// instances terminating outnumber desired and reach max size -> error
+		{inputs{
+			asgDesiredCapactiy:     9,
+			asgMaxSize:             10,
+			asgCurrentNumInstances: 11,
+			activityStatusCode:     autoscaling.ScalingActivityStatusCodeSuccessful,
+			instanceType:           ec2.InstanceTypeM44xlarge,
+			neededResources:        api.Resources{CPU: 96000, MemMB: 10},
+		}, expectedResults{
+			shouldDescribeScalingActivities: false,
+			remainingNeededResources:        api.Resources{CPU: 96000, MemMB: 10},
+			err: fmt.Errorf("Failed to scale.  Autoscaling group blah at max size."),
+		}},
 
 		// PreInService ScalingActivityStatusCodePreInService -> error
-		inputs{
+		{inputs{
 			asgDesiredCapactiy:     5,
 			asgMaxSize:             15,
 			asgCurrentNumInstances: 5,
 			activityStatusCode:     autoscaling.ScalingActivityStatusCodePreInService,
 			instanceType:           ec2.InstanceTypeM44xlarge,
 			neededResources:        api.Resources{CPU: 96000, MemMB: 10},
-		}: expectedResults{
+		}, expectedResults{
 			shouldDescribeScalingActivities:    true,
 			shouldDescribeLaunchConfigurations: false,
 			remainingNeededResources:           api.Resources{CPU: 96000, MemMB: 10},
 			err: fmt.Errorf("Autoscaling group in pre service"),
-		},
+		}},
 	}
 
 	mockCtrl := gomock.NewController(t)
@@ -365,7 +404,8 @@ func TestAttemptRemediate(t *testing.T) {
 
 	mockAutoscalingClient := NewMockAutoscalingClient(mockCtrl)
 	asgRemediator := &ASGRemediator{client: mockAutoscalingClient}
-	for in, out := range tests {
+	for _, test := range tests {
+		in, out := test.in, test.out
 		asgRemediator.MaxMachineIncrement = in.configMaxMachineIncrement
 		asgRemediator.StopIfMaximallyIncremented = in.configStopIfMaxIncrement
 		asGroup := &autoscaling.Group{
@@ -401,13 +441,386 @@ func TestAttemptRemediate(t *testing.T) {
 			}
 		}
 
-		remainingNeeded, err := asgRemediator.attemptRemediate(asGroup, &in.neededResources)
+		var audit bytes.Buffer
+		req := &rem.Request{Strategy: "0", Pods: []string{"ns/pod"}, AuditLog: rem.NewAuditLog(&audit)}
+		remainingNeeded, err := asgRemediator.attemptRemediate(asGroup, &in.neededResources, req)
 		if (err == nil) != (out.err == nil) {
 			t.Errorf("Expected error %v but got error %v when attempting to remediate", out.err, err)
 		}
-		if *remainingNeeded != out.remainingNeededResources {
+		if !remainingNeeded.Equal(&out.remainingNeededResources) {
 			t.Errorf("Expected %v resources after attempt remediate, but got %v", out.remainingNeededResources, *remainingNeeded)
 		}
+
+		var record rem.AuditRecord
+		if out.shouldSetDesiredCapacity {
+			if err := json.Unmarshal(audit.Bytes(), &record); err != nil {
+				t.Errorf("Expected an audit record. Error: %v", err)
+			} else if record.OldDesiredCapacity != in.asgDesiredCapactiy || record.NewDesiredCapacity != out.setDesiredCapacity ||
+				record.InstanceType != in.instanceType || record.Strategy != "0" || len(record.Pods) != 1 {
+				t.Errorf("Unexpected audit record %v", record)
+			}
+		} else if audit.Len() != 0 {
+			t.Errorf("Expected no audit record. Got %s", audit.String())
+		}
+	}
+
+}
+
+func TestGroupCooldown(t *testing.T) {
+	var data = `
+names:
+- foo
+cooldown: 10m
+honorCooldown: true
+`
+	asg := &ASGRemediator{}
+	if err := yaml.Unmarshal([]byte(data), &asg); err != nil {
+		t.Fatalf("Could not unmarshal: %v", err)
+	}
+
+	if asg.Cooldown == nil || asg.Cooldown.Duration != 10*time.Minute {
+		t.Errorf("Expected cooldown of 10m. Actual %v", asg.Cooldown)
+	}
+	if !asg.HonorCooldown {
+		t.Error("Expected HonorCooldown to be set")
+	}
+
+	now := time.Now()
+	if cooling, _ := asg.inCooldown("foo", now); cooling {
+		t.Error("Group in cooldown before scaling")
+	}
+
+	asg.lastScaled = map[string]time.Time{"foo": now.Add(-time.Minute)}
+	if cooling, _ := asg.inCooldown("foo", now); !cooling {
+		t.Error("Group not in cooldown after scaling")
+	}
+	if cooling, _ := asg.inCooldown("bar", now); cooling {
+		t.Error("Cooldown applied to unscaled group")
+	}
+}
+
+func TestAttemptRemediateDryRun(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAutoscalingClient := NewMockAutoscalingClient(mockCtrl)
+	asgRemediator := &ASGRemediator{client: mockAutoscalingClient}
+
+	name := aws.String("blah")
+	statusCode := autoscaling.ScalingActivityStatusCodeSuccessful
+	instanceType := ec2.InstanceTypeM44xlarge
+	asGroup := &autoscaling.Group{
+		AutoScalingGroupName:    name,
+		LaunchConfigurationName: name,
+		DesiredCapacity:         aws.Int64(5),
+		MaxSize:                 aws.Int64(15),
+		Instances:               getInstanceList(5),
+	}
+
+	mockAutoscalingClient.EXPECT().DescribeScalingActivities(gomock.Any()).Return(
+		&autoscaling.DescribeScalingActivitiesOutput{
+			Activities: []*autoscaling.Activity{&autoscaling.Activity{StatusCode: &statusCode}},
+		}, nil)
+	mockAutoscalingClient.EXPECT().DescribeLaunchConfigurations(gomock.Any()).Return(
+		&autoscaling.DescribeLaunchConfigurationsOutput{
+			LaunchConfigurations: []*autoscaling.LaunchConfiguration{&autoscaling.LaunchConfiguration{InstanceType: &instanceType}},
+		}, nil)
+	//SetDesiredCapacity must not be called during a dry run
+
+	ledger := rem.NewInFlightLedger(time.Minute)
+	req := &rem.Request{DryRun: true, Ledger: ledger}
+	remainingNeeded, err := asgRemediator.attemptRemediate(asGroup, &api.Resources{CPU: 32000, MemMB: 10}, req)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !remainingNeeded.IsEmpty() {
+		t.Errorf("Expected planned scale up to meet need. Actual %v", *remainingNeeded)
+	}
+
+	if len(req.ScaleUps) != 1 || req.ScaleUps[0].FromSize != 5 || req.ScaleUps[0].ToSize != 7 {
+		t.Errorf("Expected planned scale up from 5 to 7. Actual %v", req.ScaleUps)
+	}
+	if req.ScaledUp() {
+		t.Error("Dry run reported a scale up")
+	}
+	if len(ledger.Entries()) != 0 {
+		t.Errorf("Dry run recorded in flight capacity %v", ledger.Entries())
+	}
+}
+
+func TestAttemptRemediatePacksPods(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAutoscalingClient := NewMockAutoscalingClient(mockCtrl)
+	asgRemediator := &ASGRemediator{client: mockAutoscalingClient, MaxMachineIncrement: aws.Int(4)}
+
+	name := aws.String("blah")
+	statusCode := autoscaling.ScalingActivityStatusCodeSuccessful
+	instanceType := ec2.InstanceTypeM4Xlarge
+	asGroup := &autoscaling.Group{
+		AutoScalingGroupName:    name,
+		LaunchConfigurationName: name,
+		DesiredCapacity:         aws.Int64(2),
+		MaxSize:                 aws.Int64(15),
+		Instances:               getInstanceList(2),
+	}
+
+	mockAutoscalingClient.EXPECT().DescribeScalingActivities(gomock.Any()).Return(
+		&autoscaling.DescribeScalingActivitiesOutput{
+			Activities: []*autoscaling.Activity{&autoscaling.Activity{StatusCode: &statusCode}},
+		}, nil)
+	mockAutoscalingClient.EXPECT().DescribeLaunchConfigurations(gomock.Any()).Return(
+		&autoscaling.DescribeLaunchConfigurationsOutput{
+			LaunchConfigurations: []*autoscaling.LaunchConfiguration{&autoscaling.LaunchConfiguration{InstanceType: &instanceType}},
+		}, nil)
+
+	//Five 3 CPU pods need five 4 CPU instances even though their sum fits on four. One pod fits on no instance
+	var demands []rem.PodDemand
+	for _, pod := range []string{"a", "b", "c", "d", "e"} {
+		demands = append(demands, rem.PodDemand{Pod: "ns/" + pod, Resources: api.Resources{CPU: 3000, MemMB: 100, Pods: 1}})
+	}
+	demands = append(demands, rem.PodDemand{Pod: "ns/huge", Resources: api.Resources{CPU: 8000, Pods: 1}})
+
+	req := &rem.Request{DryRun: true, Demands: demands}
+	remainingNeeded, err := asgRemediator.attemptRemediate(asGroup, &api.Resources{CPU: 23000, MemMB: 500, Pods: 6}, req)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	if len(req.ScaleUps) != 1 || req.ScaleUps[0].Instances != 4 {
+		t.Errorf("Expected 4 instances limited by MaxMachineIncrement. Actual %v", req.ScaleUps)
+	}
+	if len(req.TooLarge) != 1 || len(req.TooLarge[0].Pods) != 1 || req.TooLarge[0].Pods[0] != "ns/huge" {
+		t.Errorf("Expected ns/huge reported too large. Actual %v", req.TooLarge)
+	}
+	if len(req.Demands) != 2 || !remainingNeeded.Equal(&api.Resources{CPU: 11000, MemMB: 100, Pods: 2}) {
+		t.Errorf("Expected one 3 CPU pod and the too large pod left. Actual %v %v", req.Demands, remainingNeeded)
+	}
+}
+
+func TestAttemptRemediateRecordsPlacedPods(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAutoscalingClient := NewMockAutoscalingClient(mockCtrl)
+	asgRemediator := &ASGRemediator{client: mockAutoscalingClient, MaxMachineIncrement: aws.Int(3)}
+
+	name := aws.String("blah")
+	statusCode := autoscaling.ScalingActivityStatusCodeSuccessful
+	instanceType := ec2.InstanceTypeM4Xlarge
+	asGroup := &autoscaling.Group{
+		AutoScalingGroupName:    name,
+		LaunchConfigurationName: name,
+		DesiredCapacity:         aws.Int64(2),
+		MaxSize:                 aws.Int64(15),
+		Instances:               getInstanceList(2),
+	}
+
+	mockAutoscalingClient.EXPECT().DescribeScalingActivities(gomock.Any()).Return(
+		&autoscaling.DescribeScalingActivitiesOutput{
+			Activities: []*autoscaling.Activity{&autoscaling.Activity{StatusCode: &statusCode}},
+		}, nil).Times(2)
+	mockAutoscalingClient.EXPECT().DescribeLaunchConfigurations(gomock.Any()).Return(
+		&autoscaling.DescribeLaunchConfigurationsOutput{
+			LaunchConfigurations: []*autoscaling.LaunchConfiguration{&autoscaling.LaunchConfiguration{InstanceType: &instanceType}},
+		}, nil).Times(2)
+	first := mockAutoscalingClient.EXPECT().SetDesiredCapacity(&autoscaling.SetDesiredCapacityInput{
+		AutoScalingGroupName: name,
+		DesiredCapacity:      aws.Int64(5),
+		HonorCooldown:        aws.Bool(false),
+	})
+	mockAutoscalingClient.EXPECT().SetDesiredCapacity(&autoscaling.SetDesiredCapacityInput{
+		AutoScalingGroupName: name,
+		DesiredCapacity:      aws.Int64(7),
+		HonorCooldown:        aws.Bool(false),
+	}).After(first)
+
+	//Five 3 CPU pods need five instances but MaxMachineIncrement only allows three per cycle
+	pods := []string{"ns/a", "ns/b", "ns/c", "ns/d", "ns/e"}
+	ledger := rem.NewInFlightLedger(time.Minute)
+	pendingDemands := func() []rem.PodDemand {
+		var demands []rem.PodDemand
+		for _, pod := range pods {
+			if !ledger.HasPendingFor([]string{pod}) {
+				demands = append(demands, rem.PodDemand{Pod: pod, Resources: api.Resources{CPU: 3000, MemMB: 100, Pods: 1}})
+			}
+		}
+		return demands
+	}
+
+	var audit bytes.Buffer
+	req := &rem.Request{Pods: pods, Ledger: ledger, Demands: pendingDemands(), AuditLog: rem.NewAuditLog(&audit)}
+	if _, err := asgRemediator.attemptRemediate(asGroup, &api.Resources{CPU: 15000, MemMB: 500, Pods: 5}, req); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	entries := ledger.Entries()
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].Pods, []string{"ns/a", "ns/b", "ns/c"}) {
+		t.Fatalf("Expected only the pods placed onto the 3 instances in flight. Actual %v", entries)
+	}
+	var record rem.AuditRecord
+	if err := json.Unmarshal(audit.Bytes(), &record); err != nil || !reflect.DeepEqual(record.Pods, []string{"ns/a", "ns/b", "ns/c"}) {
+		t.Errorf("Expected the audit record to list the placed pods. Actual %v %v", record.Pods, err)
 	}
 
+	//The next cycle asks for the two pods left out
+	asGroup.DesiredCapacity = aws.Int64(5)
+	asGroup.Instances = getInstanceList(5)
+	demands := pendingDemands()
+	if len(demands) != 2 {
+		t.Fatalf("Expected 2 pods still pending. Actual %v", demands)
+	}
+	req = &rem.Request{Pods: pods, Ledger: ledger, Demands: demands}
+	if _, err := asgRemediator.attemptRemediate(asGroup, &api.Resources{CPU: 6000, MemMB: 200, Pods: 2}, req); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(req.ScaleUps) != 1 || req.ScaleUps[0].Instances != 2 {
+		t.Errorf("Expected 2 instances for the remaining pods. Actual %v", req.ScaleUps)
+	}
+	if len(pendingDemands()) != 0 {
+		t.Errorf("Expected every pod in flight. Actual %v", ledger.Entries())
+	}
+}
+
+func TestAttemptRemediateLaunchConfigErrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockAutoscalingClient := NewMockAutoscalingClient(mockCtrl)
+	asgRemediator := &ASGRemediator{client: mockAutoscalingClient}
+	statusCode := autoscaling.ScalingActivityStatusCodeSuccessful
+
+	tests := []struct {
+		launchConfigName *string
+		output           *autoscaling.DescribeLaunchConfigurationsOutput
+		err              error
+		contains         string
+	}{
+		{
+			contains: "has no launch configuration",
+		},
+		{
+			launchConfigName: aws.String("blah"),
+			err:              fmt.Errorf("throttled"),
+			contains:         "throttled",
+		},
+		{
+			launchConfigName: aws.String("blah"),
+			output: &autoscaling.DescribeLaunchConfigurationsOutput{
+				LaunchConfigurations: []*autoscaling.LaunchConfiguration{&autoscaling.LaunchConfiguration{}},
+			},
+			contains: "has no instance type",
+		},
+	}
+
+	for _, test := range tests {
+		asGroup := &autoscaling.Group{
+			AutoScalingGroupName:    aws.String("blah"),
+			LaunchConfigurationName: test.launchConfigName,
+			DesiredCapacity:         aws.Int64(1),
+			MaxSize:                 aws.Int64(10),
+			Instances:               getInstanceList(1),
+		}
+		mockAutoscalingClient.EXPECT().DescribeScalingActivities(gomock.Any()).Return(
+			&autoscaling.DescribeScalingActivitiesOutput{
+				Activities: []*autoscaling.Activity{&autoscaling.Activity{StatusCode: &statusCode}},
+			}, nil)
+		if test.launchConfigName != nil {
+			mockAutoscalingClient.EXPECT().DescribeLaunchConfigurations(gomock.Any()).Return(test.output, test.err)
+		}
+
+		needed := &api.Resources{CPU: 1000}
+		remainingNeeded, err := asgRemediator.attemptRemediate(asGroup, needed, &rem.Request{})
+		if err == nil || !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("Expected an error containing %q. Got %v", test.contains, err)
+		}
+		if !remainingNeeded.Equal(needed) {
+			t.Errorf("Expected the need to be unchanged. Got %v", remainingNeeded)
+		}
+	}
+}
+
+type fakeOverhead struct {
+	overhead    api.Resources
+	instanceIDs []string
+}
+
+func (f *fakeOverhead) DaemonSetOverhead(instanceIDs []string) *api.Resources {
+	f.instanceIDs = instanceIDs
+	return f.overhead.Copy()
+}
+
+type fakeObserved struct {
+	group        string
+	instanceType string
+	allocatable  api.Resources
+}
+
+func (f *fakeObserved) ObservedCapacity(group, instanceType string) (*api.Resources, bool) {
+	if group != f.group || instanceType != f.instanceType {
+		return nil, false
+	}
+	return f.allocatable.Copy(), true
+}
+
+func TestNodeCapacity(t *testing.T) {
+	var data = `
+names:
+- foo
+kubeReserved:
+  cpu: 100
+  memoryMB: 512
+systemReserved:
+  cpu: 100
+  memoryMB: 256
+`
+	asg := &ASGRemediator{}
+	if err := yaml.Unmarshal([]byte(data), &asg); err != nil {
+		t.Fatalf("Could not unmarshal: %v", err)
+	}
+	if err := asg.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	asGroup := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("foo"),
+		Instances:            []*autoscaling.Instance{nil, &autoscaling.Instance{InstanceId: aws.String("i-1")}},
+	}
+	overhead := &fakeOverhead{overhead: api.Resources{CPU: 300, MemMB: 256, Pods: 2}}
+	req := &rem.Request{Overhead: overhead}
+
+	capacity, err := asg.nodeCapacity(asGroup, buildLaunchConfig(ec2.InstanceTypeM4Xlarge), req)
+	if err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if !capacity.Equal(&api.Resources{CPU: 3500, MemMB: 15360}) {
+		t.Errorf("Unexpected capacity after overhead: %v", capacity)
+	}
+	if len(overhead.instanceIDs) != 1 || overhead.instanceIDs[0] != "i-1" {
+		t.Errorf("Expected overhead for instance i-1. Got %v", overhead.instanceIDs)
+	}
+
+	overhead.overhead.CPU = 4000
+	if _, err = asg.nodeCapacity(asGroup, buildLaunchConfig(ec2.InstanceTypeM4Xlarge), req); err == nil {
+		t.Error("Expected an error when the overhead uses all CPU")
+	}
+
+	if _, err = asg.nodeCapacity(asGroup, buildLaunchConfig("Unknown"), req); err == nil || !strings.Contains(err.Error(), "not in the instance catalog") {
+		t.Errorf("Expected an unknown instance type error. Got %v", err)
+	}
+
+	//The allocatable resources of a registered node already exclude the reservations
+	req.Observed = &fakeObserved{group: "foo", instanceType: ec2.InstanceTypeM4Xlarge, allocatable: api.Resources{CPU: 3800, MemMB: 14000, Pods: 110}}
+	overhead.overhead.CPU = 300
+	if capacity, err = asg.nodeCapacity(asGroup, buildLaunchConfig(ec2.InstanceTypeM4Xlarge), req); err != nil || !capacity.Equal(&api.Resources{CPU: 3500, MemMB: 13744, Pods: 108}) {
+		t.Errorf("Unexpected capacity from a registered node: %v %v", capacity, err)
+	}
+	if capacity, err = asg.nodeCapacity(asGroup, buildLaunchConfig("custom.large"), req); err == nil {
+		t.Errorf("Expected an unknown instance type error when nodes run another type. Got %v", capacity)
+	}
+
+	asg.KubeReserved.CPU = -1
+	if err = asg.Validate(); err == nil || !strings.Contains(err.Error(), "kubeReserved") {
+		t.Errorf("Expected kubeReserved validation error. Got %v", err)
+	}
 }
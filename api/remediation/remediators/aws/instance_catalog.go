@@ -0,0 +1,239 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/glog"
+	"github.com/jmccarty3/awsScaler/api"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v2"
+)
+
+//InstanceType describes the capacity of an EC2 instance type
+type InstanceType struct {
+	Name string `yaml:"name"`
+	//CPU is in millicores
+	CPU int64 `yaml:"cpu"`
+	//MemoryMB is in MiB like pod requests
+	MemoryMB int64 `yaml:"memoryMB"`
+	GPUs     int64 `yaml:"gpus"`
+	//MaxPods is the number of pods a node of the type can run. 0 is unlimited
+	MaxPods int64 `yaml:"maxPods"`
+	//ENIPods is the pod limit of the AWS VPC CNI plugin, which gives each pod an address of the instance's ENIs.
+	//Only used as MaxPods when ENI pod limits are enabled
+	ENIPods int64 `yaml:"-"`
+}
+
+//Resources returns the capacity of one instance
+func (t *InstanceType) Resources() api.Resources {
+	r := api.Resources{
+		CPU:   t.CPU,
+		MemMB: t.MemoryMB,
+		Pods:  t.MaxPods,
+	}
+	return *r.Set(api.ResourceGPU, t.GPUs)
+}
+
+func (t *InstanceType) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("name: Missing")
+	}
+	if t.CPU <= 0 || t.MemoryMB <= 0 {
+		return fmt.Errorf("%s: cpu and memoryMB must be positive", t.Name)
+	}
+	if t.GPUs < 0 || t.MaxPods < 0 {
+		return fmt.Errorf("%s: gpus and maxPods must not be negative", t.Name)
+	}
+	return nil
+}
+
+var unknownInstanceTypes = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "aws_scaler",
+	Subsystem: "aws",
+	Name:      "unknown_instance_type_total",
+	Help:      "Remediations skipped because the instance type of a group is not in the instance catalog",
+}, []string{"instance_type"})
+
+func init() {
+	prometheus.MustRegister(unknownInstanceTypes)
+}
+
+//InstanceCatalog resolves the capacity of instance types. Types loaded from a file take precedence over types
+//discovered from EC2, which take precedence over the builtin table
+type InstanceCatalog struct {
+	lock       sync.RWMutex
+	builtin    map[string]InstanceType
+	discovered map[string]InstanceType
+	overrides  map[string]InstanceType
+	//eniPodLimits uses the ENI pod limit of types without a maxPods
+	eniPodLimits bool
+}
+
+//DefaultCatalog is used by every autoscaling group remediator
+var DefaultCatalog = NewInstanceCatalog()
+
+func indexInstanceTypes(types []InstanceType) map[string]InstanceType {
+	index := make(map[string]InstanceType, len(types))
+	for _, t := range types {
+		index[t.Name] = t
+	}
+	return index
+}
+
+//NewInstanceCatalog returns a catalog holding the builtin instance types
+func NewInstanceCatalog() *InstanceCatalog {
+	return &InstanceCatalog{builtin: indexInstanceTypes(builtinInstanceTypes)}
+}
+
+//Lookup returns the instance type with the given name
+func (c *InstanceCatalog) Lookup(name string) (InstanceType, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for _, types := range []map[string]InstanceType{c.overrides, c.discovered, c.builtin} {
+		if t, exists := types[name]; exists {
+			if t.MaxPods == 0 && c.eniPodLimits {
+				t.MaxPods = t.ENIPods
+			}
+			return t, true
+		}
+	}
+	return InstanceType{}, false
+}
+
+//SetOverrides replaces the instance types loaded from a file
+func (c *InstanceCatalog) SetOverrides(types []InstanceType) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.overrides = indexInstanceTypes(types)
+}
+
+//SetENIPodLimits limits the pods of types without a maxPods to the addresses of their ENIs, as on clusters using the AWS VPC CNI plugin.
+//Otherwise they are unlimited until the allocatable pods of a registered node are learned
+func (c *InstanceCatalog) SetENIPodLimits(enabled bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.eniPodLimits = enabled
+}
+
+//SetDiscovered replaces the instance types discovered from EC2
+func (c *InstanceCatalog) SetDiscovered(types []InstanceType) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.discovered = indexInstanceTypes(types)
+}
+
+//LoadInstanceTypes reads a YAML list of instance types
+func LoadInstanceTypes(path string) ([]InstanceType, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading instance catalog: %v", err)
+	}
+
+	var types []InstanceType
+	if err = yaml.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("Error parsing instance catalog %s: %v", path, err)
+	}
+	for i := range types {
+		if err = types[i].validate(); err != nil {
+			return nil, fmt.Errorf("Instance catalog %s [%d]: %v", path, i, err)
+		}
+	}
+	return types, nil
+}
+
+//instanceTypeDescriber lists the instance types offered by EC2
+type instanceTypeDescriber interface {
+	DescribeInstanceTypes(*ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error)
+}
+
+//instanceTypeFromInfo converts an EC2 description. Only NVIDIA GPUs are counted as they are the ones advertised as nvidia.com/gpu
+func instanceTypeFromInfo(info *ec2.InstanceTypeInfo) InstanceType {
+	t := InstanceType{Name: aws.StringValue(info.InstanceType)}
+	if info.VCpuInfo != nil {
+		t.CPU = aws.Int64Value(info.VCpuInfo.DefaultVCpus) * 1000
+	}
+	if info.MemoryInfo != nil {
+		t.MemoryMB = aws.Int64Value(info.MemoryInfo.SizeInMiB)
+	}
+	if info.GpuInfo != nil {
+		for _, gpu := range info.GpuInfo.Gpus {
+			if aws.StringValue(gpu.Manufacturer) == "NVIDIA" {
+				t.GPUs += aws.Int64Value(gpu.Count)
+			}
+		}
+	}
+	if info.NetworkInfo != nil {
+		enis, addresses := aws.Int64Value(info.NetworkInfo.MaximumNetworkInterfaces), aws.Int64Value(info.NetworkInfo.Ipv4AddressesPerInterface)
+		if enis > 0 && addresses > 0 {
+			t.ENIPods = enis*(addresses-1) + 2
+		}
+	}
+	return t
+}
+
+//describeInstanceTypes lists every instance type offered in the region
+func describeInstanceTypes(client instanceTypeDescriber) ([]InstanceType, error) {
+	var types []InstanceType
+	input := &ec2.DescribeInstanceTypesInput{}
+	for {
+		output, err := client.DescribeInstanceTypes(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range output.InstanceTypes {
+			if t := instanceTypeFromInfo(info); t.validate() == nil {
+				types = append(types, t)
+			}
+		}
+		if aws.StringValue(output.NextToken) == "" {
+			return types, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+//refresh replaces the discovered instance types with those described by EC2
+func (c *InstanceCatalog) refresh(client instanceTypeDescriber) error {
+	types, err := describeInstanceTypes(client)
+	if err != nil {
+		return err
+	}
+	c.SetDiscovered(types)
+	glog.Infof("Discovered %d instance types from EC2", len(types))
+	return nil
+}
+
+//RefreshFromEC2 describes the instance types of the region with EC2 DescribeInstanceTypes every interval until stop is closed
+func (c *InstanceCatalog) RefreshFromEC2(interval time.Duration, stop <-chan struct{}) {
+	var client instanceTypeDescriber
+	for {
+		if client == nil {
+			if region, err := lookupRegion(); err != nil {
+				glog.Warningf("Unable to refresh instance types: %v", err)
+			} else {
+				client = ec2.New(session.New(&aws.Config{
+					Credentials: getAWSCredentials(),
+					Region:      aws.String(region),
+				}))
+			}
+		}
+		if client != nil {
+			if err := c.refresh(client); err != nil {
+				glog.Warningf("Unable to refresh instance types from EC2: %v", err)
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
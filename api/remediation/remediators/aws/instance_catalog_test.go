@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/jmccarty3/awsScaler/api"
+)
+
+func TestInstanceCatalogLookup(t *testing.T) {
+	catalog := NewInstanceCatalog()
+	if m4, exists := catalog.Lookup("m4.xlarge"); !exists || m4.CPU != 4000 || m4.MaxPods != 0 || m4.ENIPods != 58 {
+		t.Errorf("Unexpected builtin m4.xlarge: %v", m4)
+	}
+	catalog.SetENIPodLimits(true)
+	if m4, _ := catalog.Lookup("m4.xlarge"); m4.MaxPods != 58 || m4.Resources().Pods != 58 {
+		t.Errorf("Expected the ENI pod limit once enabled. Got %v", m4)
+	}
+	catalog.SetENIPodLimits(false)
+	if _, exists := catalog.Lookup("custom.large"); exists {
+		t.Error("Unexpected custom.large in builtin table")
+	}
+
+	catalog.SetDiscovered([]InstanceType{{Name: "m4.xlarge", CPU: 4000, MemoryMB: 16384, MaxPods: 10}, {Name: "custom.large", CPU: 2000, MemoryMB: 4096}})
+	catalog.SetOverrides([]InstanceType{{Name: "custom.large", CPU: 2000, MemoryMB: 2048}})
+
+	if m4, _ := catalog.Lookup("m4.xlarge"); m4.MaxPods != 10 {
+		t.Errorf("Expected discovered types to override builtin types. Got %v", m4)
+	}
+	if custom, _ := catalog.Lookup("custom.large"); custom.MemoryMB != 2048 {
+		t.Errorf("Expected overrides to take precedence. Got %v", custom)
+	}
+	p2, _ := catalog.Lookup("p2.8xlarge")
+	if r := p2.Resources(); r.Get(api.ResourceGPU) != 8 {
+		t.Errorf("Expected 8 GPUs for p2.8xlarge. Got %v", r)
+	}
+}
+
+func TestLoadInstanceTypes(t *testing.T) {
+	tests := []struct {
+		data     string
+		contains string
+	}{
+		{
+			data: "- name: custom.large\n  cpu: 2000\n  memoryMB: 4096\n  gpus: 1\n",
+		},
+		{
+			data:     "- cpu: 2000\n  memoryMB: 4096\n",
+			contains: "[0]: name: Missing",
+		},
+		{
+			data:     "- name: custom.large\n  cpu: 2000\n",
+			contains: "custom.large: cpu and memoryMB must be positive",
+		},
+		{
+			data:     "name: custom.large\n",
+			contains: "Error parsing instance catalog",
+		},
+	}
+
+	for i, test := range tests {
+		file, err := ioutil.TempFile("", "catalog")
+		if err != nil {
+			t.Fatalf("Unable to create catalog: %v", err)
+		}
+		file.WriteString(test.data)
+		file.Close()
+
+		types, err := LoadInstanceTypes(file.Name())
+		os.Remove(file.Name())
+		if test.contains == "" {
+			if err != nil || len(types) != 1 || types[0].GPUs != 1 {
+				t.Errorf("Test %d: Unexpected result %v %v", i, types, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("Test %d: Expected error containing %q. Got %v", i, test.contains, err)
+		}
+	}
+}
+
+type fakeInstanceTypeDescriber struct {
+	pages []*ec2.DescribeInstanceTypesOutput
+	err   error
+}
+
+func (f *fakeInstanceTypeDescriber) DescribeInstanceTypes(input *ec2.DescribeInstanceTypesInput) (*ec2.DescribeInstanceTypesOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	page := f.pages[0]
+	f.pages = f.pages[1:]
+	return page, nil
+}
+
+func makeInstanceTypeInfo(name string, vcpus, memMiB, enis, addresses int64, gpus ...*ec2.GpuDeviceInfo) *ec2.InstanceTypeInfo {
+	return &ec2.InstanceTypeInfo{
+		InstanceType: aws.String(name),
+		VCpuInfo:     &ec2.VCpuInfo{DefaultVCpus: aws.Int64(vcpus)},
+		MemoryInfo:   &ec2.MemoryInfo{SizeInMiB: aws.Int64(memMiB)},
+		NetworkInfo:  &ec2.NetworkInfo{MaximumNetworkInterfaces: aws.Int64(enis), Ipv4AddressesPerInterface: aws.Int64(addresses)},
+		GpuInfo:      &ec2.GpuInfo{Gpus: gpus},
+	}
+}
+
+func TestRefreshFromEC2(t *testing.T) {
+	client := &fakeInstanceTypeDescriber{pages: []*ec2.DescribeInstanceTypesOutput{
+		{
+			InstanceTypes: []*ec2.InstanceTypeInfo{makeInstanceTypeInfo("m9.large", 2, 8192, 3, 10)},
+			NextToken:     aws.String("next"),
+		},
+		{
+			InstanceTypes: []*ec2.InstanceTypeInfo{
+				makeInstanceTypeInfo("g9.xlarge", 4, 16384, 4, 15,
+					&ec2.GpuDeviceInfo{Manufacturer: aws.String("NVIDIA"), Count: aws.Int64(2)},
+					&ec2.GpuDeviceInfo{Manufacturer: aws.String("AMD"), Count: aws.Int64(1)}),
+			},
+		},
+	}}
+
+	catalog := NewInstanceCatalog()
+	if err := catalog.refresh(client); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if m9, exists := catalog.Lookup("m9.large"); !exists || m9.CPU != 2000 || m9.MemoryMB != 8192 || m9.ENIPods != 29 {
+		t.Errorf("Unexpected m9.large: %v", m9)
+	}
+	if g9, exists := catalog.Lookup("g9.xlarge"); !exists || g9.GPUs != 2 || g9.ENIPods != 58 {
+		t.Errorf("Unexpected g9.xlarge: %v", g9)
+	}
+
+	//A failed refresh keeps the types discovered before
+	if err := catalog.refresh(&fakeInstanceTypeDescriber{err: errors.New("denied")}); err == nil {
+		t.Error("Expected refresh error")
+	}
+	if _, exists := catalog.Lookup("m9.large"); !exists {
+		t.Error("Discovered types lost after a failed refresh")
+	}
+}
@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+)
+
+//describeInstancesBatch is the most instance ids DescribeAutoScalingInstances accepts per call
+const describeInstancesBatch = 50
+
+//InstanceGroups maps instances to the autoscaling group they belong to.
+//Memberships are cached as an instance never moves between groups
+type InstanceGroups struct {
+	client AutoscalingClient
+	lock   sync.Mutex
+	//groups holds the group of each instance described. Instances outside any group map to ""
+	groups map[string]string
+}
+
+//NewInstanceGroups returns an empty mapping. The client is created on first use
+func NewInstanceGroups() *InstanceGroups {
+	return &InstanceGroups{groups: make(map[string]string)}
+}
+
+func (g *InstanceGroups) getClient() AutoscalingClient {
+	if g.client == nil {
+		g.client = &instrumentedClient{client: autoscaling.New(session.New(&aws.Config{
+			Credentials: getAWSCredentials(),
+			Region:      aws.String(getRegion()),
+		}))}
+	}
+	return g.client
+}
+
+//Lookup returns the group of each instance that belongs to one. Only instances not seen by the previous lookup are described
+func (g *InstanceGroups) Lookup(instanceIDs []string) (map[string]string, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	var unknown []*string
+	for _, id := range instanceIDs {
+		if _, exists := g.groups[id]; !exists {
+			unknown = append(unknown, aws.String(id))
+		}
+	}
+
+	for start := 0; start < len(unknown); start += describeInstancesBatch {
+		end := start + describeInstancesBatch
+		if end > len(unknown) {
+			end = len(unknown)
+		}
+		output, err := g.getClient().DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+			InstanceIds: unknown[start:end],
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Unable to describe autoscaling instances. Error: %v", err)
+		}
+		for _, id := range unknown[start:end] {
+			g.groups[*id] = ""
+		}
+		for _, instance := range output.AutoScalingInstances {
+			g.groups[aws.StringValue(instance.InstanceId)] = aws.StringValue(instance.AutoScalingGroupName)
+		}
+	}
+
+	//Forget instances no longer asked about so terminated instances do not accumulate
+	known := make(map[string]string, len(instanceIDs))
+	groups := make(map[string]string)
+	for _, id := range instanceIDs {
+		known[id] = g.groups[id]
+		if group := g.groups[id]; group != "" {
+			groups[id] = group
+		}
+	}
+	g.groups = known
+	return groups, nil
+}
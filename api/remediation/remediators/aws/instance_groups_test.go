@@ -0,0 +1,60 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/golang/mock/gomock"
+)
+
+func describeInstancesOutput(groups map[string]string) *autoscaling.DescribeAutoScalingInstancesOutput {
+	output := &autoscaling.DescribeAutoScalingInstancesOutput{}
+	for id, group := range groups {
+		output.AutoScalingInstances = append(output.AutoScalingInstances, &autoscaling.InstanceDetails{
+			InstanceId:           aws.String(id),
+			AutoScalingGroupName: aws.String(group),
+		})
+	}
+	return output
+}
+
+func TestInstanceGroupsLookup(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := NewMockAutoscalingClient(mockCtrl)
+	instanceGroups := NewInstanceGroups()
+	instanceGroups.client = client
+
+	var ids []string
+	for i := 0; i < describeInstancesBatch+1; i++ {
+		ids = append(ids, fmt.Sprintf("i-%d", i))
+	}
+	//The first lookup is split into batches. i-1 belongs to no group
+	first := client.EXPECT().DescribeAutoScalingInstances(gomock.Any()).Return(describeInstancesOutput(map[string]string{"i-0": "workers"}), nil)
+	client.EXPECT().DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []*string{aws.String(ids[describeInstancesBatch])},
+	}).Return(describeInstancesOutput(map[string]string{ids[describeInstancesBatch]: "gpus"}), nil).After(first)
+
+	groups, err := instanceGroups.Lookup(ids)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(groups) != 2 || groups["i-0"] != "workers" || groups[ids[describeInstancesBatch]] != "gpus" {
+		t.Errorf("Unexpected groups %v", groups)
+	}
+
+	//Known instances, including those outside any group, are not described again
+	client.EXPECT().DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: []*string{aws.String("i-new")},
+	}).Return(describeInstancesOutput(map[string]string{"i-new": "workers"}), nil)
+
+	if groups, err = instanceGroups.Lookup([]string{"i-0", "i-1", "i-new"}); err != nil || len(groups) != 2 || groups["i-new"] != "workers" {
+		t.Errorf("Unexpected groups %v %v", groups, err)
+	}
+	if len(instanceGroups.groups) != 3 {
+		t.Errorf("Expected instances no longer asked about to be forgotten. Got %v", instanceGroups.groups)
+	}
+}
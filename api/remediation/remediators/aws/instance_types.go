@@ -0,0 +1,102 @@
+package aws
+
+//builtinInstanceTypes is the capacity of common instance types. Memory is in MiB like pod requests. ENIPods is the
+//limit imposed by the ENIs and addresses per ENI of the type: ENIs * (addresses - 1) + 2
+var builtinInstanceTypes = []InstanceType{
+	{Name: "t2.micro", CPU: 1000, MemoryMB: 1024, GPUs: 0, ENIPods: 4},
+	{Name: "t2.small", CPU: 1000, MemoryMB: 2048, GPUs: 0, ENIPods: 11},
+	{Name: "t2.medium", CPU: 2000, MemoryMB: 4096, GPUs: 0, ENIPods: 17},
+	{Name: "t2.large", CPU: 2000, MemoryMB: 8192, GPUs: 0, ENIPods: 35},
+	{Name: "t2.xlarge", CPU: 4000, MemoryMB: 16384, GPUs: 0, ENIPods: 44},
+	{Name: "t2.2xlarge", CPU: 8000, MemoryMB: 32768, GPUs: 0, ENIPods: 44},
+
+	{Name: "t3.micro", CPU: 2000, MemoryMB: 1024, GPUs: 0, ENIPods: 4},
+	{Name: "t3.small", CPU: 2000, MemoryMB: 2048, GPUs: 0, ENIPods: 11},
+	{Name: "t3.medium", CPU: 2000, MemoryMB: 4096, GPUs: 0, ENIPods: 17},
+	{Name: "t3.large", CPU: 2000, MemoryMB: 8192, GPUs: 0, ENIPods: 35},
+	{Name: "t3.xlarge", CPU: 4000, MemoryMB: 16384, GPUs: 0, ENIPods: 58},
+	{Name: "t3.2xlarge", CPU: 8000, MemoryMB: 32768, GPUs: 0, ENIPods: 58},
+
+	{Name: "m3.medium", CPU: 1000, MemoryMB: 3840, GPUs: 0, ENIPods: 12},
+	{Name: "m3.large", CPU: 2000, MemoryMB: 7680, GPUs: 0, ENIPods: 29},
+	{Name: "m3.xlarge", CPU: 4000, MemoryMB: 15360, GPUs: 0, ENIPods: 58},
+	{Name: "m3.2xlarge", CPU: 8000, MemoryMB: 30720, GPUs: 0, ENIPods: 118},
+
+	{Name: "m4.large", CPU: 2000, MemoryMB: 8192, GPUs: 0, ENIPods: 20},
+	{Name: "m4.xlarge", CPU: 4000, MemoryMB: 16384, GPUs: 0, ENIPods: 58},
+	{Name: "m4.2xlarge", CPU: 8000, MemoryMB: 32768, GPUs: 0, ENIPods: 58},
+	{Name: "m4.4xlarge", CPU: 16000, MemoryMB: 65536, GPUs: 0, ENIPods: 234},
+	{Name: "m4.10xlarge", CPU: 40000, MemoryMB: 163840, GPUs: 0, ENIPods: 234},
+	{Name: "m4.16xlarge", CPU: 64000, MemoryMB: 262144, GPUs: 0, ENIPods: 234},
+
+	{Name: "m5.large", CPU: 2000, MemoryMB: 8192, GPUs: 0, ENIPods: 29},
+	{Name: "m5.xlarge", CPU: 4000, MemoryMB: 16384, GPUs: 0, ENIPods: 58},
+	{Name: "m5.2xlarge", CPU: 8000, MemoryMB: 32768, GPUs: 0, ENIPods: 58},
+	{Name: "m5.4xlarge", CPU: 16000, MemoryMB: 65536, GPUs: 0, ENIPods: 234},
+	{Name: "m5.12xlarge", CPU: 48000, MemoryMB: 196608, GPUs: 0, ENIPods: 234},
+	{Name: "m5.24xlarge", CPU: 96000, MemoryMB: 393216, GPUs: 0, ENIPods: 737},
+
+	{Name: "c3.large", CPU: 2000, MemoryMB: 3840, GPUs: 0, ENIPods: 29},
+	{Name: "c3.xlarge", CPU: 4000, MemoryMB: 7680, GPUs: 0, ENIPods: 58},
+	{Name: "c3.2xlarge", CPU: 8000, MemoryMB: 15360, GPUs: 0, ENIPods: 58},
+	{Name: "c3.4xlarge", CPU: 16000, MemoryMB: 30720, GPUs: 0, ENIPods: 234},
+	{Name: "c3.8xlarge", CPU: 32000, MemoryMB: 61440, GPUs: 0, ENIPods: 234},
+
+	{Name: "c4.large", CPU: 2000, MemoryMB: 3840, GPUs: 0, ENIPods: 29},
+	{Name: "c4.xlarge", CPU: 4000, MemoryMB: 7680, GPUs: 0, ENIPods: 58},
+	{Name: "c4.2xlarge", CPU: 8000, MemoryMB: 15360, GPUs: 0, ENIPods: 58},
+	{Name: "c4.4xlarge", CPU: 16000, MemoryMB: 30720, GPUs: 0, ENIPods: 234},
+	{Name: "c4.8xlarge", CPU: 36000, MemoryMB: 61440, GPUs: 0, ENIPods: 234},
+
+	{Name: "c5.large", CPU: 2000, MemoryMB: 4096, GPUs: 0, ENIPods: 29},
+	{Name: "c5.xlarge", CPU: 4000, MemoryMB: 8192, GPUs: 0, ENIPods: 58},
+	{Name: "c5.2xlarge", CPU: 8000, MemoryMB: 16384, GPUs: 0, ENIPods: 58},
+	{Name: "c5.4xlarge", CPU: 16000, MemoryMB: 32768, GPUs: 0, ENIPods: 234},
+	{Name: "c5.9xlarge", CPU: 36000, MemoryMB: 73728, GPUs: 0, ENIPods: 234},
+	{Name: "c5.18xlarge", CPU: 72000, MemoryMB: 147456, GPUs: 0, ENIPods: 737},
+
+	{Name: "r3.large", CPU: 2000, MemoryMB: 15616, GPUs: 0, ENIPods: 29},
+	{Name: "r3.xlarge", CPU: 4000, MemoryMB: 31232, GPUs: 0, ENIPods: 58},
+	{Name: "r3.2xlarge", CPU: 8000, MemoryMB: 62464, GPUs: 0, ENIPods: 58},
+	{Name: "r3.4xlarge", CPU: 16000, MemoryMB: 124928, GPUs: 0, ENIPods: 234},
+	{Name: "r3.8xlarge", CPU: 32000, MemoryMB: 249856, GPUs: 0, ENIPods: 234},
+
+	{Name: "r4.large", CPU: 2000, MemoryMB: 15616, GPUs: 0, ENIPods: 29},
+	{Name: "r4.xlarge", CPU: 4000, MemoryMB: 31232, GPUs: 0, ENIPods: 58},
+	{Name: "r4.2xlarge", CPU: 8000, MemoryMB: 62464, GPUs: 0, ENIPods: 58},
+	{Name: "r4.4xlarge", CPU: 16000, MemoryMB: 124928, GPUs: 0, ENIPods: 234},
+	{Name: "r4.8xlarge", CPU: 32000, MemoryMB: 249856, GPUs: 0, ENIPods: 234},
+	{Name: "r4.16xlarge", CPU: 64000, MemoryMB: 499712, GPUs: 0, ENIPods: 737},
+
+	{Name: "r5.large", CPU: 2000, MemoryMB: 16384, GPUs: 0, ENIPods: 29},
+	{Name: "r5.xlarge", CPU: 4000, MemoryMB: 32768, GPUs: 0, ENIPods: 58},
+	{Name: "r5.2xlarge", CPU: 8000, MemoryMB: 65536, GPUs: 0, ENIPods: 58},
+	{Name: "r5.4xlarge", CPU: 16000, MemoryMB: 131072, GPUs: 0, ENIPods: 234},
+	{Name: "r5.12xlarge", CPU: 48000, MemoryMB: 393216, GPUs: 0, ENIPods: 234},
+	{Name: "r5.24xlarge", CPU: 96000, MemoryMB: 786432, GPUs: 0, ENIPods: 737},
+
+	{Name: "i3.large", CPU: 2000, MemoryMB: 15616, GPUs: 0, ENIPods: 29},
+	{Name: "i3.xlarge", CPU: 4000, MemoryMB: 31232, GPUs: 0, ENIPods: 58},
+	{Name: "i3.2xlarge", CPU: 8000, MemoryMB: 62464, GPUs: 0, ENIPods: 58},
+	{Name: "i3.4xlarge", CPU: 16000, MemoryMB: 124928, GPUs: 0, ENIPods: 234},
+	{Name: "i3.8xlarge", CPU: 32000, MemoryMB: 249856, GPUs: 0, ENIPods: 234},
+	{Name: "i3.16xlarge", CPU: 64000, MemoryMB: 499712, GPUs: 0, ENIPods: 737},
+
+	{Name: "x1.16xlarge", CPU: 64000, MemoryMB: 999424, GPUs: 0, ENIPods: 234},
+	{Name: "x1.32xlarge", CPU: 128000, MemoryMB: 1998848, GPUs: 0, ENIPods: 234},
+
+	{Name: "g2.2xlarge", CPU: 8000, MemoryMB: 15360, GPUs: 1, ENIPods: 58},
+	{Name: "g2.8xlarge", CPU: 32000, MemoryMB: 61440, GPUs: 4, ENIPods: 234},
+
+	{Name: "g3.4xlarge", CPU: 16000, MemoryMB: 124928, GPUs: 1, ENIPods: 234},
+	{Name: "g3.8xlarge", CPU: 32000, MemoryMB: 249856, GPUs: 2, ENIPods: 234},
+	{Name: "g3.16xlarge", CPU: 64000, MemoryMB: 499712, GPUs: 4, ENIPods: 737},
+
+	{Name: "p2.xlarge", CPU: 4000, MemoryMB: 62464, GPUs: 1, ENIPods: 58},
+	{Name: "p2.8xlarge", CPU: 32000, MemoryMB: 499712, GPUs: 8, ENIPods: 234},
+	{Name: "p2.16xlarge", CPU: 64000, MemoryMB: 749568, GPUs: 16, ENIPods: 234},
+
+	{Name: "p3.2xlarge", CPU: 8000, MemoryMB: 62464, GPUs: 1, ENIPods: 58},
+	{Name: "p3.8xlarge", CPU: 32000, MemoryMB: 249856, GPUs: 4, ENIPods: 234},
+	{Name: "p3.16xlarge", CPU: 64000, MemoryMB: 499712, GPUs: 8, ENIPods: 234},
+}
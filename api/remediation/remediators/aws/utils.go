@@ -2,9 +2,9 @@ package aws
 
 import (
 	"errors"
+	"fmt"
 	"math"
 	"os"
-	"sync"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
@@ -12,9 +12,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/golang/glog"
 	"github.com/jmccarty3/awsScaler/api"
+	rem "github.com/jmccarty3/awsScaler/api/remediation"
 )
 
 const defaultRegion = "us-east-1"
@@ -30,6 +30,16 @@ func stringSliceContains(toSearch []string, value string) bool {
 	return false
 }
 
+func instanceIDs(group *autoscaling.Group) []string {
+	ids := make([]string, 0, len(group.Instances))
+	for _, i := range group.Instances {
+		if i != nil && i.InstanceId != nil {
+			ids = append(ids, *i.InstanceId)
+		}
+	}
+	return ids
+}
+
 //TODO Consider abstracting over these for testing purposes
 
 func getAWSCredentials() *credentials.Credentials {
@@ -47,12 +57,13 @@ func getMetadataClient() *ec2metadata.EC2Metadata {
 	return ec2metadata.New(session.New(&aws.Config{}))
 }
 
-func getRegion() string {
+//lookupRegion finds the region from the environment or the metadata service
+func lookupRegion() (string, error) {
 	//Check the environment first
 	if region, found := os.LookupEnv(envRegionName); found {
 		if region != "" {
 			glog.Infof("Using region %s from environment", region)
-			return region
+			return region, nil
 		}
 	}
 	//Attempt to check the metadata service
@@ -60,81 +71,115 @@ func getRegion() string {
 	if client.Available() {
 		region, err := client.Region()
 		if err == nil {
-			glog.Info("Metadata service returned %s region", region)
-			return region
+			glog.Infof("Metadata service returned %s region", region)
+			return region, nil
 		}
 	}
 
-	glog.Warningf("Unable to find region from Metadata service or the environment. Using default %s", defaultRegion)
-	//Give up. Use default
-	return defaultRegion
+	return "", errors.New("Unable to find region from Metadata service or the environment")
+}
+
+func getRegion() string {
+	region, err := lookupRegion()
+	if err != nil {
+		glog.Warningf("%v. Using default %s", err, defaultRegion)
+		//Give up. Use default
+		return defaultRegion
+	}
+	return region
 }
 
-var mapSync sync.Once
-var resources map[string]api.Resources
+//CheckAccess returns an error if AWS credentials or the region can not be resolved
+func CheckAccess() error {
+	if _, err := getAWSCredentials().Get(); err != nil {
+		return fmt.Errorf("Unable to resolve AWS credentials: %v", err)
+	}
+	_, err := lookupRegion()
+	return err
+}
 
+//getResourceForInstanceType returns the capacity of an instance type from the default catalog. Empty if the type is unknown
 func getResourceForInstanceType(instanceType *string) api.Resources {
-	mapSync.Do(func() {
-		resources = make(map[string]api.Resources)
-		resources[ec2.InstanceTypeT2Large] = api.Resources{
-			CPU:   2000,
-			MemMB: 8000,
-		}
-		resources[ec2.InstanceTypeC4Xlarge] = api.Resources{
-			CPU:   4000,
-			MemMB: 7500,
-		}
-		resources[ec2.InstanceTypeC42xlarge] = api.Resources{
-			CPU:   8000,
-			MemMB: 15000,
-		}
-		resources[ec2.InstanceTypeC44xlarge] = api.Resources{
-			CPU:   16000,
-			MemMB: 30000,
-		}
-		resources[ec2.InstanceTypeM4Large] = api.Resources{
-			CPU:   2000,
-			MemMB: 8000,
-		}
-		resources[ec2.InstanceTypeM4Xlarge] = api.Resources{
-			CPU:   4000,
-			MemMB: 16000,
-		}
-		resources[ec2.InstanceTypeM42xlarge] = api.Resources{
-			CPU:   8000,
-			MemMB: 32000,
+	if t, exists := DefaultCatalog.Lookup(*instanceType); exists {
+		return t.Resources()
+	}
+	return api.EmptyResources
+}
+
+//calculatedNeededServersForConfig returns the number of instances of the launch configuration, each providing capacity, needed to provide the resources.
+//Resources of unknown capacity for the instance type, such as ephemeral storage, are optimistically assumed to be provided.
+//Extended resources the instance type does not list can never be provided
+func calculatedNeededServersForConfig(config *autoscaling.LaunchConfiguration, capacity api.Resources, resources *api.Resources) (int, api.Resources, error) {
+	configResources := *capacity.Copy()
+
+	if configResources.IsEmpty() {
+		return 0, api.EmptyResources, fmt.Errorf("Capacity of instance type %s is unknown", *config.InstanceType)
+	}
+
+	val := 0
+	var unknown []string
+	for _, name := range api.Names(resources) {
+		needed, available := resources.Get(name), configResources.Get(name)
+		if needed == 0 {
+			continue
 		}
-		resources[ec2.InstanceTypeM44xlarge] = api.Resources{
-			CPU:   16000,
-			MemMB: 64000,
+		if available == 0 {
+			if _, extended := resources.Extended[name]; extended {
+				return 0, api.EmptyResources, fmt.Errorf("Instance type %s does not provide %s", *config.InstanceType, name)
+			}
+			unknown = append(unknown, name)
+			continue
 		}
-	})
+		val = int(math.Max(float64(val), math.Ceil(float64(needed)/float64(available))))
+	}
 
-	if r, exists := resources[*instanceType]; exists {
-		return r
+	//Only resources of unknown capacity are needed. One instance is assumed to provide them
+	if val == 0 {
+		val = 1
 	}
 
-	glog.Warning("Could not find instance type:", *instanceType)
-	return api.EmptyResources
+	for _, name := range unknown {
+		configResources.Set(name, int64(math.Ceil(float64(resources.Get(name))/float64(val))))
+	}
+	return val, configResources, nil
 }
 
-func calculatedNeededServersForConfig(config *autoscaling.LaunchConfiguration, resources *api.Resources) (int, api.Resources) {
-	congfigResources := getResourceForInstanceType(config.InstanceType)
-
-	if congfigResources == api.EmptyResources {
-		return 1, api.EmptyResources
+//packDemands places the pods onto instances with the given capacity. Returns nil if the capacity is unknown
+func packDemands(capacity api.Resources, demands []rem.PodDemand) *api.Packing {
+	if capacity.IsEmpty() {
+		return nil
 	}
 
-	byCPU := math.Ceil(float64(resources.CPU) / float64(congfigResources.CPU))
-	byMem := math.Ceil(float64(resources.MemMB) / float64(congfigResources.MemMB))
-
-	val := int(math.Max(byCPU, byMem))
+	pods := make([]api.Resources, len(demands))
+	for i, d := range demands {
+		pods[i] = d.Resources
+	}
+	return api.Pack(pods, &capacity)
+}
 
-	if val == 0 {
-		return 1, api.EmptyResources
+//placedPods returns the pods placed onto the first instances of a packing. Never nil so no pods means none rather than all
+func placedPods(demands []rem.PodDemand, packing *api.Packing, instances int) []string {
+	placed := []string{}
+	for n := 0; n < instances && n < len(packing.Nodes); n++ {
+		for _, i := range packing.Nodes[n] {
+			placed = append(placed, demands[i].Pod)
+		}
 	}
+	return placed
+}
 
-	return val, congfigResources
+//unplacedDemands returns the pods not placed onto the first instances of a packing, including pods too large for any instance
+func unplacedDemands(demands []rem.PodDemand, packing *api.Packing, instances int) []rem.PodDemand {
+	var unplaced []rem.PodDemand
+	for n := instances; n < len(packing.Nodes); n++ {
+		for _, i := range packing.Nodes[n] {
+			unplaced = append(unplaced, demands[i])
+		}
+	}
+	for _, i := range packing.TooLarge {
+		unplaced = append(unplaced, demands[i])
+	}
+	return unplaced
 }
 
 func getLaunchConfig(client AutoscalingClient, configName string) (*autoscaling.LaunchConfiguration, error) {
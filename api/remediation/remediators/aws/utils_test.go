@@ -79,15 +79,58 @@ func TestCalculateServers(t *testing.T) {
 		},
 		{
 			config:          buildLaunchConfig("Unknown"),
-			resourcesNeeded: makeResources(0, 0),
-			expected:        1,
+			resourcesNeeded: makeResources(1000, 1000),
+			expected:        0,
 			test:            "Unknown Type",
 		},
+		{
+			config:          buildLaunchConfig(ec2.InstanceTypeM4Large),
+			resourcesNeeded: &api.Resources{CPU: 100, MemMB: 100, Pods: 41},
+			expected:        1,
+			test:            "Pods Unlimited Without ENI Pod Limits",
+		},
+		{
+			config:          buildLaunchConfig(ec2.InstanceTypeM42xlarge),
+			resourcesNeeded: &api.Resources{CPU: 100, MemMB: 100, EphemeralStorageMB: 500000},
+			expected:        1,
+			test:            "Unknown Ephemeral Storage Capacity",
+		},
+		{
+			config:          buildLaunchConfig(ec2.InstanceTypeM42xlarge),
+			resourcesNeeded: &api.Resources{EphemeralStorageMB: 500000},
+			expected:        1,
+			test:            "Only Unknown Ephemeral Storage Capacity",
+		},
+		{
+			config:          buildLaunchConfig("p2.8xlarge"),
+			resourcesNeeded: makeResources(1000, 1000).Set(api.ResourceGPU, 12),
+			expected:        2,
+			test:            "GPUs Exceed Limit",
+		},
+		{
+			config:          buildLaunchConfig(ec2.InstanceTypeM42xlarge),
+			resourcesNeeded: makeResources(1000, 1000).Set(api.ResourceGPU, 1),
+			expected:        0,
+			test:            "GPUs Not Provided",
+		},
 	}
 
 	for _, test := range tests {
-		if actual, _ := calculatedNeededServersForConfig(test.config, test.resourcesNeeded); actual != test.expected {
+		actual, _, err := calculatedNeededServersForConfig(test.config, getResourceForInstanceType(test.config.InstanceType), test.resourcesNeeded)
+		if actual != test.expected {
 			t.Errorf("%s Failed. Expected: %d, Got: %d", test.test, test.expected, actual)
 		}
+		if (err != nil) != (test.expected == 0) {
+			t.Errorf("%s Failed. Unexpected error: %v", test.test, err)
+		}
+	}
+}
+
+func TestInstanceResourcesCopied(t *testing.T) {
+	instanceType := "p2.xlarge"
+	scaled := getResourceForInstanceType(&instanceType)
+	scaled.Scale(4)
+	if r := getResourceForInstanceType(&instanceType); r.Get(api.ResourceGPU) != 1 {
+		t.Errorf("Scaling modified the instance table. Got %v", r)
 	}
 }
@@ -1,5 +1,6 @@
 package remediators
 
 import (
-	_ "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws" //Letting it register
+	_ "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"     //Letting it register
+	_ "github.com/jmccarty3/awsScaler/api/remediation/remediators/webhook" //Letting it register
 )
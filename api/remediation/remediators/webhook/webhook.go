@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/jmccarty3/awsScaler/api"
+	rem "github.com/jmccarty3/awsScaler/api/remediation"
+	"github.com/pkg/errors"
+)
+
+//RemediatorName name to use when registering the remediator
+const RemediatorName = "webhook"
+
+//DefaultTimeout is used for requests when no timeout is configured
+const DefaultTimeout = 10 * time.Second
+
+//DefaultCooldown is how long the same pods are not posted again when no cooldown is configured
+const DefaultCooldown = 10 * time.Minute
+
+//Config used for marshalling data to/from yaml
+type Config struct {
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	//Body is a text/template rendered with the Payload. The Payload is posted as JSON when empty
+	Body    string        `yaml:"body"`
+	Timeout *api.Duration `yaml:"timeout"`
+	//Resolves treats a successful request as remediating all needed resources
+	Resolves bool `yaml:"resolves"`
+	//Cooldown is how long pods already posted are not posted again. 0 posts every cycle
+	Cooldown *api.Duration `yaml:"cooldown"`
+}
+
+//Payload describes the unmet need. It is posted as JSON and is the data for the body template
+type Payload struct {
+	Strategy string        `json:"strategy"`
+	Needed   api.Resources `json:"needed"`
+	Pods     []string      `json:"pods"`
+	Time     time.Time     `json:"time"`
+}
+
+//Remediator posts unmet resource needs to a webhook
+type Remediator struct {
+	Config
+
+	body   *template.Template
+	client *http.Client
+	//notified holds when each pod was last posted successfully
+	notified map[string]time.Time
+}
+
+func newRemediator(config rem.ConfigData) rem.Remediator {
+	return &Remediator{}
+}
+
+func init() {
+	rem.RegisterRemediator(RemediatorName, newRemediator)
+}
+
+//UnmarshalYAML is used to unmarshal the remediator from yaml config
+func (r *Remediator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	config := &Config{}
+	err := unmarshal(&config)
+	r.Config = *config
+	return err
+}
+
+//Validate ensures the URL and body template are usable
+func (r *Remediator) Validate() error {
+	if r.URL == "" {
+		return errors.New("url: Required")
+	}
+	if u, err := url.Parse(r.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url: Expected an absolute http or https URL. Got %q", r.URL)
+	}
+
+	if r.Timeout != nil && r.Timeout.Duration < 0 {
+		return fmt.Errorf("timeout: Must not be negative. Got %v", r.Timeout.Duration)
+	}
+	if r.Cooldown != nil && r.Cooldown.Duration < 0 {
+		return fmt.Errorf("cooldown: Must not be negative. Got %v", r.Cooldown.Duration)
+	}
+
+	if r.Body != "" {
+		body, err := template.New(RemediatorName).Parse(r.Body)
+		if err != nil {
+			return fmt.Errorf("body: %v", err)
+		}
+		r.body = body
+	}
+	return nil
+}
+
+func (r *Remediator) getClient() *http.Client {
+	if r.client == nil {
+		timeout := DefaultTimeout
+		if r.Timeout != nil && r.Timeout.Duration > 0 {
+			timeout = r.Timeout.Duration
+		}
+		r.client = &http.Client{Timeout: timeout}
+	}
+	return r.client
+}
+
+func (r *Remediator) getCooldown() time.Duration {
+	if r.Cooldown == nil {
+		return DefaultCooldown
+	}
+	return r.Cooldown.Duration
+}
+
+//inCooldown returns true if every pod was posted within the cooldown
+func (r *Remediator) inCooldown(pods []string, now time.Time) bool {
+	if len(pods) == 0 {
+		return false
+	}
+	for _, pod := range pods {
+		if now.Sub(r.notified[pod]) >= r.getCooldown() {
+			return false
+		}
+	}
+	return true
+}
+
+//markNotified records the pods posted and forgets pods whose cooldown ended
+func (r *Remediator) markNotified(pods []string, now time.Time) {
+	if r.notified == nil {
+		r.notified = make(map[string]time.Time)
+	}
+	for pod, notified := range r.notified {
+		if now.Sub(notified) >= r.getCooldown() {
+			delete(r.notified, pod)
+		}
+	}
+	for _, pod := range pods {
+		r.notified[pod] = now
+	}
+}
+
+//render builds the request body and its content type
+func (r *Remediator) render(payload *Payload) ([]byte, string, error) {
+	if r.body == nil {
+		data, err := json.Marshal(payload)
+		return data, "application/json", err
+	}
+
+	var buf bytes.Buffer
+	if err := r.body.Execute(&buf, payload); err != nil {
+		return nil, "", errors.Wrap(err, "Failed to render webhook body")
+	}
+	return buf.Bytes(), "text/plain", nil
+}
+
+//Remediate posts the needed resources and triggering pods to the webhook.
+//Nothing is posted while every pod was already posted within the cooldown.
+//The need is only considered met if the remediator is configured to resolve it
+func (r *Remediator) Remediate(needed *api.Resources, req *rem.Request) (*api.Resources, error) {
+	now := time.Now()
+	if r.inCooldown(req.Pods, now) {
+		glog.V(2).Infof("Webhook %s already notified of all %d pods within %v", r.URL, len(req.Pods), r.getCooldown())
+		if r.Resolves {
+			return &api.EmptyResources, nil
+		}
+		return needed, nil
+	}
+
+	payload := &Payload{
+		Strategy: req.Strategy,
+		Needed:   *needed.Copy(),
+		Pods:     req.Pods,
+		Time:     now,
+	}
+
+	body, contentType, err := r.render(payload)
+	if err != nil {
+		return needed, err
+	}
+
+	if req.IsDryRun() {
+		glog.Infof("Dry run. Would post %d bytes to webhook %s for %d pods", len(body), r.URL, len(req.Pods))
+		return needed, nil
+	}
+
+	httpReq, err := http.NewRequest("POST", r.URL, bytes.NewReader(body))
+	if err != nil {
+		return needed, err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	for key, value := range r.Headers {
+		httpReq.Header.Set(key, value)
+	}
+	httpReq.Cancel = req.Done()
+
+	resp, err := r.getClient().Do(httpReq)
+	if err != nil {
+		return needed, errors.Wrapf(err, "Failed to post to webhook %s", r.URL)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return needed, fmt.Errorf("Webhook %s returned %s", r.URL, resp.Status)
+	}
+
+	glog.Infof("Notified webhook %s of %v needed for %d pods", r.URL, needed, len(req.Pods))
+	r.markNotified(req.Pods, now)
+	if r.Resolves {
+		return &api.EmptyResources, nil
+	}
+	return needed, nil
+}
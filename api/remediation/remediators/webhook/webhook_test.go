@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmccarty3/awsScaler/api"
+	rem "github.com/jmccarty3/awsScaler/api/remediation"
+	"gopkg.in/yaml.v2"
+)
+
+func newTestRemediator(t *testing.T, config string) *Remediator {
+	r := &Remediator{}
+	if err := yaml.UnmarshalStrict([]byte(config), r); err != nil {
+		t.Fatalf("Unexpected unmarshalling error: %v", err)
+	}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+	return r
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		config   string
+		contains string
+	}{
+		{config: "url: http://example.com/hook"},
+		{config: "headers:\n  X-Token: abc", contains: "url: Required"},
+		{config: "url: example.com/hook", contains: "absolute http or https URL"},
+		{config: "url: http://example.com\ntimeout: -1s", contains: "timeout"},
+		{config: "url: http://example.com\ncooldown: -1m", contains: "cooldown"},
+		{config: "url: http://example.com\nbody: '{{.Needed'", contains: "body"},
+	}
+
+	for i, test := range tests {
+		r := &Remediator{}
+		if err := yaml.UnmarshalStrict([]byte(test.config), r); err != nil {
+			t.Fatalf("Test %d: Unexpected unmarshalling error: %v", i, err)
+		}
+		err := r.Validate()
+		if test.contains == "" && err != nil {
+			t.Errorf("Test %d: Unexpected error: %v", i, err)
+		}
+		if test.contains != "" && (err == nil || !strings.Contains(err.Error(), test.contains)) {
+			t.Errorf("Test %d: Expected error containing %q. Got %v", i, test.contains, err)
+		}
+	}
+}
+
+func TestRemediate(t *testing.T) {
+	var received []*http.Request
+	var bodies []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	needed := &api.Resources{CPU: 3000, MemMB: 1024}
+	req := &rem.Request{Strategy: "0", Pods: []string{"alpha/a", "alpha/b"}}
+
+	//JSON payload by default. The need is left for later remediators
+	r := newTestRemediator(t, "url: "+server.URL+"\nheaders:\n  X-Token: abc")
+	remaining, err := r.Remediate(needed, req)
+	if err != nil || !remaining.Equal(needed) {
+		t.Errorf("Expected need to remain without error. Got %v %v", remaining, err)
+	}
+	var payload Payload
+	if err := json.Unmarshal([]byte(bodies[0]), &payload); err != nil || !payload.Needed.Equal(needed) || len(payload.Pods) != 2 || payload.Strategy != "0" {
+		t.Errorf("Unexpected payload %s %v", bodies[0], err)
+	}
+	if received[0].Header.Get("X-Token") != "abc" || received[0].Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers %v", received[0].Header)
+	}
+
+	//Templated body resolving the need
+	r = newTestRemediator(t, "url: "+server.URL+"\nresolves: true\nbody: 'Need {{.Needed.CPU}}m CPU for {{len .Pods}} pods'")
+	if remaining, err = r.Remediate(needed, req); err != nil || !remaining.IsEmpty() {
+		t.Errorf("Expected need to be resolved. Got %v %v", remaining, err)
+	}
+	if bodies[1] != "Need 3000m CPU for 2 pods" {
+		t.Errorf("Unexpected body %q", bodies[1])
+	}
+
+	//Failed requests leave the need unresolved
+	status = http.StatusInternalServerError
+	if remaining, err = r.Remediate(needed, &rem.Request{Strategy: "0", Pods: []string{"alpha/c"}}); err == nil || !remaining.Equal(needed) {
+		t.Errorf("Expected error and unresolved need. Got %v %v", remaining, err)
+	}
+
+	//Dry runs do not post
+	if _, err = r.Remediate(needed, &rem.Request{DryRun: true}); err != nil || len(received) != 3 {
+		t.Errorf("Expected no request during dry run. Got %d requests %v", len(received), err)
+	}
+}
+
+func TestRemediateCooldown(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	needed := &api.Resources{CPU: 3000}
+	r := newTestRemediator(t, "url: "+server.URL)
+	for i := 0; i < 3; i++ {
+		if remaining, err := r.Remediate(needed, &rem.Request{Pods: []string{"alpha/a"}}); err != nil || !remaining.Equal(needed) {
+			t.Errorf("Expected need to remain without error. Got %v %v", remaining, err)
+		}
+	}
+	if received != 1 {
+		t.Errorf("Expected a still pending pod to be posted once per cooldown. Got %d requests", received)
+	}
+
+	//A new pod is posted straight away
+	if _, err := r.Remediate(needed, &rem.Request{Pods: []string{"alpha/a", "alpha/b"}}); err != nil || received != 2 {
+		t.Errorf("Expected a new pod to be posted. Got %d requests %v", received, err)
+	}
+
+	//The pods are posted again once the cooldown ends
+	for pod := range r.notified {
+		r.notified[pod] = r.notified[pod].Add(-DefaultCooldown)
+	}
+	if _, err := r.Remediate(needed, &rem.Request{Pods: []string{"alpha/a"}}); err != nil || received != 3 {
+		t.Errorf("Expected the pod to be posted after the cooldown. Got %d requests %v", received, err)
+	}
+
+	//A cooldown of 0 posts every cycle
+	r = newTestRemediator(t, "url: "+server.URL+"\ncooldown: 0s")
+	r.Remediate(needed, &rem.Request{Pods: []string{"alpha/a"}})
+	r.Remediate(needed, &rem.Request{Pods: []string{"alpha/a"}})
+	if received != 5 {
+		t.Errorf("Expected every cycle to be posted without a cooldown. Got %d requests", received)
+	}
+}
@@ -0,0 +1,212 @@
+package remediation
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/jmccarty3/awsScaler/api"
+)
+
+//NodeRegistry reports whether an instance has registered with the cluster as a node
+type NodeRegistry interface {
+	IsRegistered(instanceID string) bool
+}
+
+//NodeOverhead reports capacity of a new node taken before pending pods are placed on it
+type NodeOverhead interface {
+	//DaemonSetOverhead returns the requests of the DaemonSet pods that will run on a new node joining the given instances
+	DaemonSetOverhead(instanceIDs []string) *api.Resources
+}
+
+//CapacityObserver reports the capacity of registered nodes
+type CapacityObserver interface {
+	//ObservedCapacity returns the allocatable resources of the newest registered node of the group running the instance type
+	ObservedCapacity(group, instanceType string) (*api.Resources, bool)
+}
+
+//ScaleUp describes a capacity increase requested, or planned during a dry run, by a remediator
+type ScaleUp struct {
+	Group     string
+	FromSize  int64
+	ToSize    int64
+	Instances int
+	Capacity  api.Resources
+	//Pods are the keys of the pods the scale up provides for. Nil means every pod of the request
+	Pods []string
+}
+
+//PodDemand is the capacity a single pod needs
+type PodDemand struct {
+	Pod       string
+	Resources api.Resources
+}
+
+//TooLarge lists pods that do not fit onto a single instance of a group
+type TooLarge struct {
+	Group        string
+	InstanceType string
+	Pods         []string
+}
+
+//Request carries information about the current remediation cycle to remediators
+type Request struct {
+	//Strategy identifies the strategy performing the remediation
+	Strategy string
+	//Pods are the keys of the pods the remediation is for
+	Pods []string
+	//Ledger records capacity requested by remediators. May be nil
+	Ledger *InFlightLedger
+	//Nodes reports registered nodes. May be nil
+	Nodes NodeRegistry
+	//Overhead reports the DaemonSet overhead of new nodes. May be nil
+	Overhead NodeOverhead
+	//Observed reports the capacity of registered nodes. May be nil
+	Observed CapacityObserver
+	//Stop is closed when the remediation should be aborted. May be nil
+	Stop <-chan struct{}
+	//DryRun plans the remediation without changing any capacity
+	DryRun bool
+	//AuditLog records every capacity change requested. May be nil
+	AuditLog *AuditLog
+	//Demands holds the capacity each pod still needs. Remediators packing pods onto instances replace it with the pods they did not provide for.
+	//Pods with capacity already in flight are left out
+	Demands []PodDemand
+
+	//ScaleUps holds the scale ups performed, or planned during a dry run, in order
+	ScaleUps []ScaleUp
+	//TooLarge holds the pods found too large for the instances of a group
+	TooLarge []TooLarge
+}
+
+//IsDryRun returns true if remediators must not change any capacity
+func (r *Request) IsDryRun() bool {
+	return r != nil && r.DryRun
+}
+
+//RecordScaleUp records capacity requested from a group for the pods of the scale up, or all of the request's pods.
+//Planned scale ups from a dry run are not tracked as in flight
+func (r *Request) RecordScaleUp(scaleUp ScaleUp) {
+	if r == nil {
+		return
+	}
+
+	r.ScaleUps = append(r.ScaleUps, scaleUp)
+	if r.Ledger == nil || r.DryRun {
+		return
+	}
+
+	pods := scaleUp.Pods
+	if pods == nil {
+		pods = r.Pods
+	}
+	r.Ledger.Add(&InFlight{
+		Group:      scaleUp.Group,
+		Instances:  scaleUp.Instances,
+		Capacity:   scaleUp.Capacity,
+		TargetSize: scaleUp.ToSize,
+		Pods:       pods,
+		Requested:  time.Now(),
+	})
+}
+
+//DaemonSetOverhead returns the requests of the DaemonSet pods that will run on a new node joining the given instances
+func (r *Request) DaemonSetOverhead(instanceIDs []string) *api.Resources {
+	if r == nil || r.Overhead == nil {
+		return &api.Resources{}
+	}
+	return r.Overhead.DaemonSetOverhead(instanceIDs)
+}
+
+//ObservedCapacity returns the allocatable resources of a registered node of the group running the instance type
+func (r *Request) ObservedCapacity(group, instanceType string) (*api.Resources, bool) {
+	if r == nil || r.Observed == nil {
+		return nil, false
+	}
+	return r.Observed.ObservedCapacity(group, instanceType)
+}
+
+//PendingDemands returns the pods still needing capacity
+func (r *Request) PendingDemands() []PodDemand {
+	if r == nil {
+		return nil
+	}
+	return r.Demands
+}
+
+//SetPendingDemands replaces the pods still needing capacity and returns the capacity they need in total
+func (r *Request) SetPendingDemands(demands []PodDemand) *api.Resources {
+	total := &api.Resources{}
+	for i := range demands {
+		total.Add(&demands[i].Resources)
+	}
+	if r != nil {
+		r.Demands = demands
+	}
+	return total
+}
+
+//RecordTooLarge records pods that do not fit onto a single instance of a group
+func (r *Request) RecordTooLarge(tooLarge TooLarge) {
+	if r == nil {
+		return
+	}
+	r.TooLarge = append(r.TooLarge, tooLarge)
+}
+
+//Audit records a capacity change requested by a remediator along with its outcome.
+//Records without pods are attributed to every pod of the request
+func (r *Request) Audit(record AuditRecord, err error) {
+	if r == nil || r.AuditLog == nil {
+		return
+	}
+
+	record.Time = time.Now()
+	record.Strategy = r.Strategy
+	if record.Pods == nil {
+		record.Pods = r.Pods
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if writeErr := r.AuditLog.Write(record); writeErr != nil {
+		glog.Errorf("Failed to write audit record for group %s: %v", record.Group, writeErr)
+	}
+}
+
+//Done returns a channel closed when the remediation should be aborted
+func (r *Request) Done() <-chan struct{} {
+	if r == nil {
+		return nil
+	}
+	return r.Stop
+}
+
+//Stopped returns true if the remediation should be aborted
+func (r *Request) Stopped() bool {
+	select {
+	case <-r.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+//ScaledUp returns true if any remediator changed capacity during the request
+func (r *Request) ScaledUp() bool {
+	return r != nil && !r.DryRun && len(r.ScaleUps) > 0
+}
+
+//ReleaseRegistered releases in flight capacity for the group whose instances have registered as nodes
+func (r *Request) ReleaseRegistered(group string, instanceIDs []string) []*InFlight {
+	if r == nil || r.Ledger == nil || r.Nodes == nil {
+		return nil
+	}
+
+	registered := 0
+	for _, id := range instanceIDs {
+		if r.Nodes.IsRegistered(id) {
+			registered++
+		}
+	}
+	return r.Ledger.Release(group, registered)
+}
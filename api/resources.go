@@ -1,26 +1,177 @@
 package api
 
-import "math"
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+//Names of the resources tracked by Resources. Any other name is an extended resource
+const (
+	ResourceCPU              = "cpu"
+	ResourceMemory           = "memory"
+	ResourcePods             = "pods"
+	ResourceEphemeralStorage = "ephemeral-storage"
+	//ResourceGPU is the extended resource advertised by the NVIDIA device plugin
+	ResourceGPU = "nvidia.com/gpu"
+)
 
 //EmptyResources placeholder to represent no resources
 var EmptyResources = Resources{CPU: 0, MemMB: 0}
 
-//Resources represents the CPU and Memory (in MB) for an object
+//Resources represents a vector of resources needed by or available on an object.
+//CPU is in millicores, memory and ephemeral storage are in MB and Pods is a count of pods
 type Resources struct {
-	CPU   int64
-	MemMB int64
+	CPU                int64
+	MemMB              int64
+	Pods               int64            `json:",omitempty"`
+	EphemeralStorageMB int64            `json:",omitempty"`
+	Extended           map[string]int64 `json:",omitempty"` //Extended holds extended resources such as nvidia.com/gpu by name
+}
+
+//Get returns the quantity of the named resource
+func (r *Resources) Get(name string) int64 {
+	switch name {
+	case ResourceCPU:
+		return r.CPU
+	case ResourceMemory:
+		return r.MemMB
+	case ResourcePods:
+		return r.Pods
+	case ResourceEphemeralStorage:
+		return r.EphemeralStorageMB
+	}
+	return r.Extended[name]
+}
+
+//Set sets the quantity of the named resource. Extended resources set to 0 are removed
+func (r *Resources) Set(name string, value int64) *Resources {
+	switch name {
+	case ResourceCPU:
+		r.CPU = value
+	case ResourceMemory:
+		r.MemMB = value
+	case ResourcePods:
+		r.Pods = value
+	case ResourceEphemeralStorage:
+		r.EphemeralStorageMB = value
+	default:
+		if value == 0 {
+			delete(r.Extended, name)
+			break
+		}
+		if r.Extended == nil {
+			r.Extended = make(map[string]int64)
+		}
+		r.Extended[name] = value
+	}
+	return r
+}
+
+//Names returns the names of every resource tracked by any of the given objects, standard resources first
+func Names(resources ...*Resources) []string {
+	names := []string{ResourceCPU, ResourceMemory, ResourcePods, ResourceEphemeralStorage}
+	seen := make(map[string]bool)
+	var extended []string
+	for _, r := range resources {
+		for name := range r.Extended {
+			if !seen[name] {
+				seen[name] = true
+				extended = append(extended, name)
+			}
+		}
+	}
+	sort.Strings(extended)
+	return append(names, extended...)
+}
+
+//Copy returns a copy of the object that does not share extended resources
+func (r *Resources) Copy() *Resources {
+	c := *r
+	c.Extended = nil
+	for name, value := range r.Extended {
+		c.Set(name, value)
+	}
+	return &c
 }
 
 //Scale scales the resource values by given value
 func (r *Resources) Scale(scaler int64) *Resources {
-	r.CPU *= scaler
-	r.MemMB *= scaler
+	for _, name := range Names(r) {
+		r.Set(name, r.Get(name)*scaler)
+	}
+	return r
+}
+
+//Add adds the specified resource amount to the object
+func (r *Resources) Add(toAdd *Resources) *Resources {
+	for _, name := range Names(r, toAdd) {
+		r.Set(name, r.Get(name)+toAdd.Get(name))
+	}
 	return r
 }
 
 //Remove removes the specified resource amound from the object. Returns 0 or remaining for each
 func (r *Resources) Remove(toRemove *Resources) *Resources {
-	r.CPU = int64(math.Max(float64(0), float64(r.CPU-toRemove.CPU)))
-	r.MemMB = int64(math.Max(float64(0), float64(r.MemMB-toRemove.MemMB)))
+	for _, name := range Names(r, toRemove) {
+		remaining := r.Get(name) - toRemove.Get(name)
+		if remaining < 0 {
+			remaining = 0
+		}
+		r.Set(name, remaining)
+	}
 	return r
 }
+
+//IsEmpty returns true if no resource has a quantity
+func (r *Resources) IsEmpty() bool {
+	for _, name := range Names(r) {
+		if r.Get(name) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//Equal returns true if every resource has the same quantity in both objects
+func (r *Resources) Equal(other *Resources) bool {
+	for _, name := range Names(r, other) {
+		if r.Get(name) != other.Get(name) {
+			return false
+		}
+	}
+	return true
+}
+
+//Fits returns true if every resource fits within the given capacity
+func (r *Resources) Fits(capacity *Resources) bool {
+	return len(r.Exceeding(capacity)) == 0
+}
+
+//Exceeding returns the names of the resources larger than the given capacity
+func (r *Resources) Exceeding(capacity *Resources) []string {
+	var names []string
+	for _, name := range Names(r, capacity) {
+		if r.Get(name) > capacity.Get(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+//String lists the resources with a quantity
+func (r Resources) String() string {
+	var buf bytes.Buffer
+	for _, name := range Names(&r) {
+		if value := r.Get(name); value != 0 {
+			if buf.Len() > 0 {
+				buf.WriteString(" ")
+			}
+			fmt.Fprintf(&buf, "%s=%d", name, value)
+		}
+	}
+	if buf.Len() == 0 {
+		return "none"
+	}
+	return buf.String()
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func gpus(cpu, count int64) *Resources {
+	r := &Resources{CPU: cpu}
+	return r.Set(ResourceGPU, count)
+}
+
+func TestResourcesArithmetic(t *testing.T) {
+	r := gpus(1000, 2)
+	r.Add(&Resources{MemMB: 512, Pods: 1}).Scale(3)
+	if !r.Equal(&Resources{CPU: 3000, MemMB: 1536, Pods: 3, Extended: map[string]int64{ResourceGPU: 6}}) {
+		t.Errorf("Unexpected result of add and scale: %v", r)
+	}
+
+	r.Remove(gpus(4000, 6))
+	if !r.Equal(&Resources{MemMB: 1536, Pods: 3}) {
+		t.Errorf("Unexpected result of remove: %v", r)
+	}
+	if len(r.Extended) != 0 {
+		t.Errorf("Expected extended resources removed at 0. Got %v", r.Extended)
+	}
+
+	r.Remove(&Resources{MemMB: 2000, Pods: 3})
+	if !r.IsEmpty() {
+		t.Errorf("Expected empty resources. Got %v", r)
+	}
+}
+
+func TestResourcesFits(t *testing.T) {
+	capacity := &Resources{CPU: 4000, MemMB: 16000, Pods: 20}
+	tests := []struct {
+		needed    *Resources
+		exceeding []string
+	}{
+		{
+			needed: &Resources{CPU: 4000, MemMB: 8000, Pods: 1},
+		},
+		{
+			needed:    &Resources{CPU: 4001, MemMB: 8000, Pods: 21},
+			exceeding: []string{ResourceCPU, ResourcePods},
+		},
+		{
+			needed:    gpus(1000, 1),
+			exceeding: []string{ResourceGPU},
+		},
+		{
+			needed:    &Resources{EphemeralStorageMB: 1},
+			exceeding: []string{ResourceEphemeralStorage},
+		},
+	}
+
+	for i, test := range tests {
+		if fits := test.needed.Fits(capacity); fits != (test.exceeding == nil) {
+			t.Errorf("Test %d: Expected fits to be %v", i, !fits)
+		}
+		if actual := test.needed.Exceeding(capacity); !reflect.DeepEqual(actual, test.exceeding) {
+			t.Errorf("Test %d: Expected %v exceeding. Got %v", i, test.exceeding, actual)
+		}
+	}
+}
+
+func TestResourcesCopy(t *testing.T) {
+	r := gpus(1000, 1)
+	c := r.Copy().Scale(2)
+	if r.Get(ResourceGPU) != 1 || c.Get(ResourceGPU) != 2 {
+		t.Errorf("Expected copy to be independent. Original %v Copy %v", r, c)
+	}
+	if s := c.String(); s != "cpu=2000 nvidia.com/gpu=2" {
+		t.Errorf("Unexpected string %q", s)
+	}
+}
@@ -3,6 +3,7 @@ package strategy
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -16,9 +17,17 @@ import (
 
 //RemediationStrategy represents a strategy to resolve unscheduled pods
 type RemediationStrategy struct {
+	//Name identifies strategies loaded from a ScalingStrategy resource. Empty for strategies from the configuration file
+	Name         string
 	Namespaces   *rapi.NamespaceCondition `yaml:",flow"`
 	NodeSelector *rapi.NodeSelectorCondition
 	Remediators  []remediation.Remediator
+	//Cooldown is the minimum time between scale ups performed by the strategy
+	Cooldown time.Duration
+	//DryRun plans remediations without changing any capacity
+	DryRun bool
+
+	lastScaleUp time.Time
 }
 
 //remediationStrategyYaml represents a simplified representation of a RemediationStrategy used for unmarshalling
@@ -26,6 +35,8 @@ type remediationStrategyYaml struct {
 	Namespaces   []string                    `yaml:"namespaces,flow"`
 	NodeSelector *rapi.NodeSelectorCondition `yaml:"nodeSelector,flow"`
 	Remediators  []map[string]interface{}    `yaml:"remediators"`
+	Cooldown     rapi.Duration               `yaml:"cooldown"`
+	DryRun       bool                        `yaml:"dryRun"`
 }
 
 func prettyPrintValueStats(statObj reflect.Value) {
@@ -49,8 +60,37 @@ func prettyPrintValueStats(statObj reflect.Value) {
 	}
 }
 
+//strategyKeys are the keys allowed within a strategy
+var strategyKeys = []string{"namespaces", "nodeSelector", "remediators", "cooldown", "dryRun"}
+
+//checkKeys returns an error naming the first key not in allowed
+func checkKeys(in map[string]interface{}, allowed []string) error {
+	for key := range in {
+		found := false
+		for _, a := range allowed {
+			if key == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("Unknown key %q. Expected one of %v", key, allowed)
+		}
+	}
+	return nil
+}
+
 //UnmarshalYAML performs custom unmarshalling from yaml
+//Unknown keys, strategies without remediators and invalid remediator configuration are rejected
 func (s *RemediationStrategy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	keys := map[string]interface{}{}
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	if err := checkKeys(keys, strategyKeys); err != nil {
+		return err
+	}
+
 	in := &remediationStrategyYaml{}
 
 	if err := unmarshal(&in); err != nil {
@@ -64,26 +104,45 @@ func (s *RemediationStrategy) UnmarshalYAML(unmarshal func(interface{}) error) e
 	}
 
 	s.NodeSelector = in.NodeSelector
+	s.Cooldown = in.Cooldown.Duration
+	s.DryRun = in.DryRun
+
+	if s.Cooldown < 0 {
+		return fmt.Errorf("cooldown: Must not be negative. Got %v", s.Cooldown)
+	}
+
+	if len(in.Remediators) == 0 {
+		return fmt.Errorf("remediators: At least one remediator is required")
+	}
+
+	for i, remediatorMap := range in.Remediators {
+		if len(remediatorMap) != 1 {
+			return fmt.Errorf("remediators[%d]: Expected exactly one remediator type. Found %d", i, len(remediatorMap))
+		}
 
-	for _, remediatorMap := range in.Remediators {
 		for remediatorName, remediatorData := range remediatorMap {
 			create, err := remediation.GetRemediatorCreator(remediatorName)
 			if err != nil {
 				glog.Errorf("%v", err)
-				return err
+				return fmt.Errorf("remediators[%d]: %v", i, err)
 			}
 			remediator := create(remediation.ConfigData{})
 
 			//Remarshal the downstream data for processing
 			reMarsh, _ := yaml.Marshal(remediatorData)
-			if err = yaml.Unmarshal(reMarsh, remediator); err != nil {
+			if err = yaml.UnmarshalStrict(reMarsh, remediator); err != nil {
 				glog.Errorf("Error unmarshalling remediator %s with data %v", remediatorName, remediatorData)
-				return err
+				return fmt.Errorf("remediators[%d].%s: %v", i, remediatorName, err)
+			}
+
+			if validator, ok := remediator.(remediation.Validator); ok {
+				if err = validator.Validate(); err != nil {
+					return fmt.Errorf("remediators[%d].%s: %v", i, remediatorName, err)
+				}
 			}
 			s.Remediators = append(s.Remediators, remediator)
 		}
 	}
-	s.Remediators = append(s.Remediators)
 	return nil
 }
 
@@ -104,19 +163,98 @@ func (s *RemediationStrategy) FilterPods(pods []*kapi.Pod) (canRemediate, remain
 	return
 }
 
+//InCooldown returns true and the time the cooldown ends if the strategy recently scaled up
+func (s *RemediationStrategy) InCooldown(now time.Time) (bool, time.Time) {
+	until := s.lastScaleUp.Add(s.Cooldown)
+	return s.Cooldown > 0 && now.Before(until), until
+}
+
+//LastScaleUp returns the last time the strategy scaled up. Zero if it never did
+func (s *RemediationStrategy) LastScaleUp() time.Time {
+	return s.lastScaleUp
+}
+
+//LastScaledGroups returns the last time the strategy's remediators scaled each group
+func (s *RemediationStrategy) LastScaledGroups() map[string]time.Time {
+	groups := make(map[string]time.Time)
+	for _, r := range s.Remediators {
+		if cooldowns, ok := r.(remediation.GroupCooldowns); ok {
+			for name, scaled := range cooldowns.LastScaled() {
+				if scaled.After(groups[name]) {
+					groups[name] = scaled
+				}
+			}
+		}
+	}
+	return groups
+}
+
+//RestoreCooldowns carries over the cooldowns of the strategy and groups it replaces. Earlier times than those known are ignored
+func (s *RemediationStrategy) RestoreCooldowns(lastScaleUp time.Time, groups map[string]time.Time) {
+	if lastScaleUp.After(s.lastScaleUp) {
+		s.lastScaleUp = lastScaleUp
+	}
+	for _, r := range s.Remediators {
+		if cooldowns, ok := r.(remediation.GroupCooldowns); ok {
+			cooldowns.RestoreLastScaled(groups)
+		}
+	}
+}
+
+//ResolveGroups returns the groups the strategy's remediators currently resolve to in the order they are tried
+func (s *RemediationStrategy) ResolveGroups() ([]string, error) {
+	var groups []string
+	for _, r := range s.Remediators {
+		if resolver, ok := r.(remediation.GroupResolver); ok {
+			resolved, err := resolver.ResolveGroups()
+			if err != nil {
+				return groups, err
+			}
+			groups = append(groups, resolved...)
+		}
+	}
+	return groups, nil
+}
+
+//DescribeGroups describes the groups the strategy's remediators currently resolve to in the order they are tried
+func (s *RemediationStrategy) DescribeGroups() ([]remediation.GroupDescription, error) {
+	var groups []remediation.GroupDescription
+	for _, r := range s.Remediators {
+		if describer, ok := r.(remediation.GroupDescriber); ok {
+			described, err := describer.DescribeGroups()
+			if err != nil {
+				return groups, err
+			}
+			groups = append(groups, described...)
+		}
+	}
+	return groups, nil
+}
+
 //DoRemediation attempt to do remediation
 //Can only optimistically scale based on resources
-func (s *RemediationStrategy) DoRemediation(resources *rapi.Resources) (remainingResources *rapi.Resources, err error) {
+func (s *RemediationStrategy) DoRemediation(resources *rapi.Resources, req *remediation.Request) (remainingResources *rapi.Resources, err error) {
 	remainingResources = resources
+	defer func() {
+		if req.ScaledUp() {
+			s.lastScaleUp = time.Now()
+		}
+	}()
 
 	for _, r := range s.Remediators {
+		if req.Stopped() {
+			err = fmt.Errorf("Remediation cancelled. Missing: %v", remainingResources)
+			glog.Warning(err)
+			return
+		}
+
 		glog.Infof("Calling remediator for %v resources", remainingResources)
 		var remErr error
-		remainingResources, remErr = r.Remediate(remainingResources)
+		remainingResources, remErr = r.Remediate(remainingResources, req)
 		if remErr != nil {
 			glog.Warning("Error remediating resources:", remErr)
 		}
-		if *remainingResources == rapi.EmptyResources {
+		if remainingResources.IsEmpty() {
 			glog.Info("All resourcess remediated")
 			return
 		}
@@ -2,6 +2,7 @@ package strategy
 
 import (
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
@@ -149,3 +150,76 @@ func TestFilterPods(t *testing.T) {
 		}
 	}
 }
+
+func TestRemediationStrategyCooldown(t *testing.T) {
+	var testConfig = `
+cooldown: 5m
+remediators:
+- autoScalingGroup:
+    names:
+    - foo
+`
+	var strat RemediationStrategy
+	if err := yaml.Unmarshal([]byte(testConfig), &strat); err != nil {
+		t.Fatalf("Unexpected unmarshaling error %v", err)
+	}
+
+	if strat.Cooldown != 5*time.Minute {
+		t.Errorf("Expected cooldown of 5m. Actual %v", strat.Cooldown)
+	}
+
+	now := time.Now()
+	if cooling, _ := strat.InCooldown(now); cooling {
+		t.Error("Strategy in cooldown before scaling")
+	}
+
+	strat.lastScaleUp = now.Add(-time.Minute)
+	if cooling, _ := strat.InCooldown(now); !cooling {
+		t.Error("Strategy not in cooldown after scaling")
+	}
+
+	strat.lastScaleUp = now.Add(-10 * time.Minute)
+	if cooling, _ := strat.InCooldown(now); cooling {
+		t.Error("Strategy in cooldown after cooldown expired")
+	}
+
+	if err := yaml.Unmarshal([]byte("cooldown: soon"), &RemediationStrategy{}); err == nil {
+		t.Error("Expected error for invalid cooldown")
+	}
+}
+
+type countingRemediator struct {
+	calls int
+}
+
+func (c *countingRemediator) Remediate(needed *rapi.Resources, req *remediation.Request) (*rapi.Resources, error) {
+	c.calls++
+	return &rapi.EmptyResources, nil
+}
+
+func TestDoRemediationCancelled(t *testing.T) {
+	counter := &countingRemediator{}
+	strat := RemediationStrategy{
+		Remediators: []remediation.Remediator{counter},
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	remaining, err := strat.DoRemediation(&rapi.Resources{CPU: 1000}, &remediation.Request{Stop: stop})
+	if err == nil {
+		t.Error("Expected error for cancelled remediation")
+	}
+	if counter.calls != 0 {
+		t.Errorf("Remediator called %d times after cancellation", counter.calls)
+	}
+	if !remaining.Equal(&rapi.Resources{CPU: 1000}) {
+		t.Errorf("Expected resources to remain. Actual %v", *remaining)
+	}
+
+	if _, err = strat.DoRemediation(&rapi.Resources{CPU: 1000}, &remediation.Request{}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if counter.calls != 1 {
+		t.Errorf("Expected remediator to be called once. Actual %d", counter.calls)
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 
+	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
 	"github.com/jmccarty3/awsScaler/api/strategy"
 
 	"gopkg.in/yaml.v2"
@@ -11,6 +13,89 @@ import (
 //Config represents configuration information for the scaler
 type Config struct {
 	Strategies []strategy.RemediationStrategy `yaml:"strategies"`
+	//InstanceCatalog is the path of a YAML file adding or overriding instance types
+	InstanceCatalog string `yaml:"instanceCatalog"`
+	//InstanceTypes are loaded from InstanceCatalog
+	InstanceTypes []raws.InstanceType `yaml:"-"`
+	//ENIPodLimits limits the pods of instance types without a maxPods to the addresses of their ENIs
+	ENIPodLimits bool `yaml:"eniPodLimits"`
+}
+
+//applyInstanceCatalog makes the instance types and pod limits of the config used by the autoscaling group remediators
+func (c *Config) applyInstanceCatalog() {
+	raws.DefaultCatalog.SetOverrides(c.InstanceTypes)
+	raws.DefaultCatalog.SetENIPodLimits(c.ENIPodLimits)
+}
+
+//UnmarshalYAML unmarshals the config, rejecting unknown keys and reporting the index of invalid strategies
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	keys := map[string]interface{}{}
+	if err := unmarshal(&keys); err != nil {
+		return err
+	}
+	for key := range keys {
+		if key != "strategies" && key != "instanceCatalog" && key != "eniPodLimits" {
+			return fmt.Errorf("Unknown key %q. Expected strategies, instanceCatalog or eniPodLimits", key)
+		}
+	}
+
+	var in struct {
+		Strategies      []interface{} `yaml:"strategies"`
+		InstanceCatalog string        `yaml:"instanceCatalog"`
+		ENIPodLimits    bool          `yaml:"eniPodLimits"`
+	}
+	if err := unmarshal(&in); err != nil {
+		return err
+	}
+	c.InstanceCatalog = in.InstanceCatalog
+	c.ENIPodLimits = in.ENIPodLimits
+
+	if len(in.Strategies) == 0 {
+		return fmt.Errorf("strategies: At least one strategy is required")
+	}
+
+	c.Strategies = nil
+	for i, strategyData := range in.Strategies {
+		var s strategy.RemediationStrategy
+
+		//Remarshal each strategy so errors can be attributed to its index
+		reMarsh, _ := yaml.Marshal(strategyData)
+		if err := yaml.Unmarshal(reMarsh, &s); err != nil {
+			return fmt.Errorf("strategies[%d]: %v", i, err)
+		}
+		c.Strategies = append(c.Strategies, s)
+	}
+
+	return nil
+}
+
+//loadConfig reads and parses the configuration file at path
+func loadConfig(path string) (*Config, error) {
+	configData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading config file: %v", err)
+	}
+
+	return parseConfig(configData)
+}
+
+//parseConfig parses and validates configuration data
+func parseConfig(configData []byte) (*Config, error) {
+	var config Config
+
+	if err := yaml.Unmarshal(configData, &config); err != nil {
+		return nil, fmt.Errorf("Error parsing config file: %v", err)
+	}
+
+	if config.InstanceCatalog != "" {
+		types, err := raws.LoadInstanceTypes(config.InstanceCatalog)
+		if err != nil {
+			return nil, fmt.Errorf("instanceCatalog: %v", err)
+		}
+		config.InstanceTypes = types
+	}
+
+	return &config, nil
 }
 
 //TODO Remove this
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/cache"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/controller/framework"
+	"k8s.io/kubernetes/pkg/fields"
+)
+
+//configMapWatcher applies the configuration stored in a ConfigMap whenever it changes
+type configMapWatcher struct {
+	client    *kclient.Client
+	namespace string
+	name      string
+	key       string
+	last      string
+	apply     func(*Config)
+
+	controller *framework.Controller
+}
+
+//parseConfigMapRef splits a namespace/name reference
+func parseConfigMapRef(ref string) (namespace, name string, err error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Invalid config map %q. Expected namespace/name", ref)
+	}
+	return parts[0], parts[1], nil
+}
+
+//configFromConfigMap parses the configuration stored under key in the ConfigMap
+func configFromConfigMap(cm *api.ConfigMap, key string) (*Config, error) {
+	data, exists := cm.Data[key]
+	if !exists {
+		return nil, fmt.Errorf("Config map %s/%s has no key %q", cm.Namespace, cm.Name, key)
+	}
+	return parseConfig([]byte(data))
+}
+
+//newConfigMapWatcher creates a watcher calling apply with each new valid configuration in the ConfigMap
+func newConfigMapWatcher(client *kclient.Client, namespace, name, key string, apply func(*Config)) *configMapWatcher {
+	w := &configMapWatcher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		key:       key,
+		apply:     apply,
+	}
+
+	_, w.controller = framework.NewInformer(
+		cache.NewListWatchFromClient(client, "configmaps", namespace, fields.OneTermEqualSelector("metadata.name", name)),
+		&api.ConfigMap{},
+		0,
+		framework.ResourceEventHandlerFuncs{
+			AddFunc: w.update,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				w.update(newObj)
+			},
+			DeleteFunc: func(oldObj interface{}) {
+				glog.Warningf("Config map %s/%s deleted. Keeping current config", w.namespace, w.name)
+			},
+		},
+	)
+	return w
+}
+
+//Load fetches and parses the ConfigMap's current configuration
+func (w *configMapWatcher) Load() (*Config, error) {
+	cm, err := w.client.ConfigMaps(w.namespace).Get(w.name)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch config map %s/%s: %v", w.namespace, w.name, err)
+	}
+
+	config, err := configFromConfigMap(cm, w.key)
+	if err != nil {
+		return nil, err
+	}
+
+	w.last = cm.Data[w.key]
+	return config, nil
+}
+
+//update applies the ConfigMap if its configuration changed. An invalid configuration is logged and the current one kept
+func (w *configMapWatcher) update(obj interface{}) {
+	cm, ok := obj.(*api.ConfigMap)
+	if !ok || cm.Data[w.key] == w.last {
+		return
+	}
+	w.last = cm.Data[w.key]
+
+	config, err := configFromConfigMap(cm, w.key)
+	if err != nil {
+		glog.Errorf("Invalid config in config map %s/%s. Keeping current config: %v", w.namespace, w.name, err)
+		return
+	}
+
+	glog.Infof("Loaded %d strategies from config map %s/%s", len(config.Strategies), w.namespace, w.name)
+	w.apply(config)
+}
+
+//Run watches the ConfigMap until stop is closed
+func (w *configMapWatcher) Run(stop <-chan struct{}) {
+	w.controller.Run(stop)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"k8s.io/kubernetes/pkg/api"
+)
+
+func TestParseConfigMapRef(t *testing.T) {
+	tests := []struct {
+		ref       string
+		namespace string
+		name      string
+		valid     bool
+	}{
+		{ref: "kube-system/aws-scaler", namespace: "kube-system", name: "aws-scaler", valid: true},
+		{ref: "aws-scaler", valid: false},
+		{ref: "/aws-scaler", valid: false},
+		{ref: "a/b/c", valid: false},
+	}
+
+	for _, test := range tests {
+		namespace, name, err := parseConfigMapRef(test.ref)
+		if (err == nil) != test.valid {
+			t.Errorf("Ref %q Expected valid: %v Actual error: %v", test.ref, test.valid, err)
+			continue
+		}
+		if namespace != test.namespace || name != test.name {
+			t.Errorf("Ref %q Expected %s/%s Actual %s/%s", test.ref, test.namespace, test.name, namespace, name)
+		}
+	}
+}
+
+func TestConfigMapWatcherUpdate(t *testing.T) {
+	var applied []*Config
+	watcher := &configMapWatcher{
+		namespace: "kube-system",
+		name:      "aws-scaler",
+		key:       "config.yaml",
+		apply: func(c *Config) {
+			applied = append(applied, c)
+		},
+	}
+
+	makeConfigMap := func(data string) *api.ConfigMap {
+		return &api.ConfigMap{
+			ObjectMeta: api.ObjectMeta{Namespace: "kube-system", Name: "aws-scaler"},
+			Data:       map[string]string{"config.yaml": data},
+		}
+	}
+
+	watcher.update(makeConfigMap(testConfig))
+	if len(applied) != 1 {
+		t.Fatalf("Expected config to be applied. Applied %d times", len(applied))
+	}
+
+	watcher.update(makeConfigMap(testConfig))
+	if len(applied) != 1 {
+		t.Error("Unchanged config was applied")
+	}
+
+	watcher.update(makeConfigMap("strategies: []"))
+	if len(applied) != 1 {
+		t.Error("Invalid config was applied")
+	}
+
+	watcher.update(&api.ConfigMap{Data: map[string]string{"other": testConfig}})
+	if len(applied) != 1 {
+		t.Error("Config map without key was applied")
+	}
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
@@ -50,3 +53,140 @@ func TestLoadConfig(t *testing.T) {
 
 	fmt.Printf("Config.Strat %v\n", config.Strategies)
 }
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		config   string
+		contains string
+	}{
+		{
+			config: `
+strategy:
+- remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+`,
+			contains: `Unknown key "strategy"`,
+		},
+		{
+			config: `
+strategies: []
+`,
+			contains: "At least one strategy",
+		},
+		{
+			config: `
+strategies:
+- remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+- namespace:
+  - alpha
+  remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+`,
+			contains: `strategies[1]: Unknown key "namespace"`,
+		},
+		{
+			config: `
+strategies:
+- namespaces:
+  - alpha
+`,
+			contains: "strategies[0]: remediators: At least one remediator",
+		},
+		{
+			config: `
+strategies:
+- remediators:
+  - autoScalingGroup:
+      maxMachineIncrement: 2
+`,
+			contains: "strategies[0]: remediators[0].autoScalingGroup: At least one of names, tags or selfTags",
+		},
+		{
+			config: `
+strategies:
+- remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+      maxMachineIncrement: -1
+`,
+			contains: "maxMachineIncrement: Must not be negative",
+		},
+		{
+			config: `
+strategies:
+- remediators:
+  - autoScalingGroup:
+      name:
+      - foo
+`,
+			contains: "strategies[0]: remediators[0].autoScalingGroup:",
+		},
+		{
+			config: `
+strategies:
+- remediators:
+  - unknownRemediator:
+      names:
+      - foo
+`,
+			contains: "strategies[0]: remediators[0]: unknownRemediator is not registered",
+		},
+	}
+
+	for _, test := range tests {
+		var config Config
+		err := yaml.Unmarshal([]byte(test.config), &config)
+		if err == nil {
+			t.Errorf("Expected error containing %q for config %s", test.contains, test.config)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("Expected error containing %q. Actual %v", test.contains, err)
+		}
+	}
+}
+
+func TestInstanceCatalogConfig(t *testing.T) {
+	catalog, err := ioutil.TempFile("", "catalog")
+	if err != nil {
+		t.Fatalf("Unable to create catalog: %v", err)
+	}
+	defer os.Remove(catalog.Name())
+	catalog.WriteString(`
+- name: m4.xlarge
+  cpu: 4000
+  memoryMB: 15000
+  maxPods: 58
+- name: custom.large
+  cpu: 2000
+  memoryMB: 4000
+`)
+	catalog.Close()
+
+	config, err := parseConfig([]byte(testConfig + "instanceCatalog: " + catalog.Name() + "\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(config.InstanceTypes) != 2 || config.InstanceTypes[1].Name != "custom.large" {
+		t.Errorf("Unexpected instance types: %v", config.InstanceTypes)
+	}
+	if config.ENIPodLimits {
+		t.Errorf("Expected ENI pod limits to be disabled by default")
+	}
+
+	if config, err = parseConfig([]byte(testConfig + "eniPodLimits: true\n")); err != nil || !config.ENIPodLimits {
+		t.Errorf("Expected ENI pod limits to be enabled. Got %v", err)
+	}
+
+	if _, err = parseConfig([]byte(testConfig + "instanceCatalog: /does/not/exist.yaml\n")); err == nil || !strings.Contains(err.Error(), "instanceCatalog") {
+		t.Errorf("Expected an instanceCatalog error. Got %v", err)
+	}
+}
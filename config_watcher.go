@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+//configFileWatcher reloads the configuration file when its contents change or SIGHUP is received
+type configFileWatcher struct {
+	path  string
+	last  []byte
+	apply func(*Config)
+}
+
+//newConfigFileWatcher creates a watcher calling apply with each new valid configuration.
+//The file's current contents are assumed to already be applied
+func newConfigFileWatcher(path string, apply func(*Config)) *configFileWatcher {
+	w := &configFileWatcher{
+		path:  path,
+		apply: apply,
+	}
+	w.last, _ = ioutil.ReadFile(path)
+	return w
+}
+
+//reload applies the file if it changed since the last reload. An unchanged file is never reapplied as applying
+//rebuilds the strategies. An invalid file is logged and the current configuration kept
+func (w *configFileWatcher) reload() {
+	data, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		glog.Errorf("Unable to read config file %s. Keeping current config: %v", w.path, err)
+		return
+	}
+
+	if bytes.Equal(data, w.last) {
+		glog.V(4).Infof("Config file %s unchanged", w.path)
+		return
+	}
+	w.last = data
+
+	config, err := parseConfig(data)
+	if err != nil {
+		glog.Errorf("Invalid config file %s. Keeping current config: %v", w.path, err)
+		return
+	}
+
+	glog.Infof("Loaded %d strategies from %s", len(config.Strategies), w.path)
+	w.apply(config)
+}
+
+//Run checks the file every interval and on SIGHUP until stop is closed. An interval of 0 only reloads on SIGHUP
+func (w *configFileWatcher) Run(interval time.Duration, stop <-chan struct{}) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-hup:
+			glog.Infof("Received SIGHUP. Reloading %s if changed", w.path)
+			w.reload()
+		case <-tick:
+			w.reload()
+		}
+	}
+}
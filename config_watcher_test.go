@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestConfigFileWatcherReload(t *testing.T) {
+	file, err := ioutil.TempFile("", "awsScaler-config")
+	if err != nil {
+		t.Fatalf("Unable to create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	write := func(data string) {
+		if err := ioutil.WriteFile(file.Name(), []byte(data), 0644); err != nil {
+			t.Fatalf("Unable to write config: %v", err)
+		}
+	}
+	write(testConfig)
+
+	var applied []*Config
+	watcher := newConfigFileWatcher(file.Name(), func(c *Config) {
+		applied = append(applied, c)
+	})
+
+	watcher.reload()
+	if len(applied) != 0 {
+		t.Errorf("Unchanged config applied %d times", len(applied))
+	}
+
+	write(testConfig + `
+- namespaces:
+  - alpha
+  remediators:
+  - autoScalingGroup:
+      names:
+      - bar
+`)
+	watcher.reload()
+	if len(applied) != 1 || len(applied[0].Strategies) != 2 {
+		t.Fatalf("Expected changed config with 2 strategies to be applied. Actual %v", applied)
+	}
+
+	write("strategies: []")
+	watcher.reload()
+	if len(applied) != 1 {
+		t.Error("Invalid config was applied")
+	}
+
+	write(testConfig)
+	watcher.reload()
+	watcher.reload()
+	if len(applied) != 2 {
+		t.Errorf("Expected only the changed config to apply. Applied %d times", len(applied))
+	}
+}
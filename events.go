@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/record"
+)
+
+//Event reasons recorded on pods the scaler acts on
+const (
+	TriggeredScaleUpReason        = "TriggeredScaleUp"
+	DryRunScaleUpReason           = "DryRunScaleUp"
+	FailedScaleUpReason           = "FailedScaleUp"
+	NoMatchingStrategyReason      = "NoMatchingStrategy"
+	NotRemediableReason           = "NotRemediable"
+	GaveUpAfterRemediationsReason = "GaveUpAfterRemediations"
+	TooLargeForInstanceTypeReason = "TooLargeForInstanceType"
+)
+
+//recordPodEvents records the same event on each pod. Nothing is recorded without a recorder
+func recordPodEvents(recorder record.EventRecorder, pods []*api.Pod, eventType, reason, messageFmt string, args ...interface{}) {
+	if recorder == nil {
+		return
+	}
+	message := fmt.Sprintf(messageFmt, args...)
+	for _, pod := range pods {
+		recorder.Event(pod, eventType, reason, message)
+	}
+}
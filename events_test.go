@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmccarty3/awsScaler/api/remediation"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/record"
+)
+
+func TestRecordRemediationEvents(t *testing.T) {
+	tests := []struct {
+		dryRun   bool
+		err      error
+		placed   []string
+		expected []string
+	}{
+		{
+			expected: []string{TriggeredScaleUpReason + " Group foo +3 (2 -> 5)"},
+		},
+		{
+			dryRun:   true,
+			expected: []string{DryRunScaleUpReason + " Group foo +3 (2 -> 5)"},
+		},
+		{
+			err:      errors.New("Unable to remediate all resources"),
+			expected: []string{TriggeredScaleUpReason, FailedScaleUpReason + " Unable to remediate all resources"},
+		},
+		{
+			placed:   []string{"ns/a"},
+			expected: []string{TriggeredScaleUpReason + " Group foo +3 (2 -> 5)"},
+		},
+		{
+			//Pods not placed onto the new instances are not told the group scaled for them
+			placed:   []string{"ns/other"},
+			expected: []string{},
+		},
+	}
+
+	pods := []*api.Pod{makePod("ns", "a", "1")}
+	for i, test := range tests {
+		recorder := record.NewFakeRecorder(10)
+		k := &kubeDataProvider{recorder: recorder}
+		req := &remediation.Request{DryRun: test.dryRun}
+		req.RecordScaleUp(remediation.ScaleUp{Group: "foo", FromSize: 2, ToSize: 5, Instances: 3, Pods: test.placed})
+
+		k.recordRemediationEvents(pods, req, test.err)
+		if len(recorder.Events) != len(test.expected) {
+			t.Errorf("Test %d: Expected %d events. Actual %d", i, len(test.expected), len(recorder.Events))
+			continue
+		}
+		for _, expected := range test.expected {
+			if event := <-recorder.Events; !strings.Contains(event, expected) {
+				t.Errorf("Test %d: Expected event containing %q. Actual %q", i, expected, event)
+			}
+		}
+	}
+}
+
+func TestRecordTooLargeEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	k := &kubeDataProvider{recorder: recorder}
+	req := &remediation.Request{}
+	req.RecordTooLarge(remediation.TooLarge{Group: "foo", InstanceType: "m4.large", Pods: []string{"ns/b"}})
+
+	k.recordRemediationEvents([]*api.Pod{makePod("ns", "a", "1"), makePod("ns", "b", "1")}, req, nil)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected 1 event. Actual %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, TooLargeForInstanceTypeReason+" Pod does not fit on an empty m4.large instance of group foo") {
+		t.Errorf("Unexpected event %q", event)
+	}
+}
+
+func TestRecordPodEventsWithoutRecorder(t *testing.T) {
+	//Must not panic when events are disabled
+	recordPodEvents(nil, []*api.Pod{makePod("ns", "a", "1")}, api.EventTypeWarning, NoMatchingStrategyReason, "No scaling strategy matches this pod")
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"sync"
+	"time"
 
 	"k8s.io/kubernetes/pkg/api"
 
@@ -10,32 +11,116 @@ import (
 
 //FailedPod represents a pod that has failed to be scheduled
 type FailedPod struct {
-	Remediations int //TODO: I don't see where this is ever used
+	Remediations int
 	Pod          *api.Pod
+	Failure      ScheduleFailure
+	GaveUpAt     time.Time
+	FirstSeen    time.Time
+	//Strategy identifies the strategy that last matched the pod
+	Strategy string
+	//Classification is why the pod was last found not remediable or unmatched. Events are only recorded when it changes
+	Classification string
 }
 
 //FailedPods is a collection of pods that have failed to schedule
 type FailedPods struct {
 	failedPods map[string]*FailedPod
+	//failures holds the latest scheduler failure for pods, including ones not yet considered failed
+	failures map[string]ScheduleFailure
+	//givenUp holds pods that exceeded maxRemediations. They are not remediated until readmitted
+	givenUp map[string]*FailedPod
+
+	maxRemediations int
+	resetWindow     time.Duration
 
 	//This can likely be changed over to a channel if performance becomes an issue
 	lock sync.Mutex
 }
 
 //NewFailedPods represents pods that have failed to schedule
-func NewFailedPods() *FailedPods {
+//Pods remediated more than maxRemediations times are given up on until their spec changes
+//or resetWindow passes. A resetWindow of 0 only readmits on spec changes
+func NewFailedPods(maxRemediations int, resetWindow time.Duration) *FailedPods {
 	return &FailedPods{
-		failedPods: make(map[string]*FailedPod),
+		failedPods:      make(map[string]*FailedPod),
+		failures:        make(map[string]ScheduleFailure),
+		givenUp:         make(map[string]*FailedPod),
+		maxRemediations: maxRemediations,
+		resetWindow:     resetWindow,
 	}
 }
 
 func (f *FailedPods) addPod(name string, pod *api.Pod) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
+
+	if p, exists := f.givenUp[name]; exists {
+		if !f.shouldReadmit(p, pod) {
+			p.Pod = pod
+			return
+		}
+		glog.Infof("Readmitting pod %s for remediation", name)
+		delete(f.givenUp, name)
+	}
+
+	if p, exists := f.failedPods[name]; exists && p.Pod.UID == pod.UID {
+		p.Pod = pod
+		return
+	}
+
 	glog.V(4).Infof("Adding Pod: %s", name)
 	f.failedPods[name] = &FailedPod{
-		Remediations: 0, //TODO: Should this be reset to zero if the pod's already present in the list?
+		Remediations: 0,
 		Pod:          pod,
+		Failure:      f.failureFor(name),
+		FirstSeen:    time.Now(),
+	}
+}
+
+//shouldReadmit checks if a given up pod has changed or waited long enough to be tried again. Caller must hold the lock
+func (f *FailedPods) shouldReadmit(p *FailedPod, pod *api.Pod) bool {
+	if p.Pod.UID != pod.UID || !api.Semantic.DeepEqual(p.Pod.Spec, pod.Spec) {
+		return true
+	}
+
+	return f.resetWindow > 0 && time.Since(p.GaveUpAt) >= f.resetWindow
+}
+
+//failureFor returns the known failure for the pod. Caller must hold the lock
+func (f *FailedPods) failureFor(name string) ScheduleFailure {
+	if failure, exists := f.failures[name]; exists {
+		return failure
+	}
+	return ScheduleFailure{Reason: UnknownScheduleIssue}
+}
+
+//setFailure records the latest scheduler failure for a pod. Older failures are ignored
+func (f *FailedPods) setFailure(name string, failure ScheduleFailure) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if existing, exists := f.failures[name]; exists && failure.Seen.Before(existing.Seen) {
+		return
+	}
+
+	glog.V(4).Infof("Pod %s failed scheduling. Reason: %s Message: %s", name, failure.Reason, failure.Message)
+	f.failures[name] = failure
+	if p, exists := f.failedPods[name]; exists {
+		p.Failure = failure
+	}
+}
+
+//pruneFailures removes recorded failures for pods no longer needing them
+func (f *FailedPods) pruneFailures(keep func(name string) bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for name := range f.failures {
+		_, failed := f.failedPods[name]
+		_, gaveUp := f.givenUp[name]
+		if !failed && !gaveUp && !keep(name) {
+			delete(f.failures, name)
+		}
 	}
 }
 
@@ -53,17 +138,126 @@ func (f *FailedPods) getPods() []*api.Pod {
 	return pods
 }
 
+//getPodsByCause splits the failed pods into those that scaling may fix and those blocked for other reasons
+func (f *FailedPods) getPodsByCause() (remediable []*api.Pod, blocked []*FailedPod) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, p := range f.failedPods {
+		if isRemediableReason(p.Failure.Reason) {
+			remediable = append(remediable, p.Pod)
+		} else {
+			blocked = append(blocked, p)
+		}
+	}
+	return
+}
+
+//countByReason counts the failed and given up pods by their scheduling failure reason
+func (f *FailedPods) countByReason() map[string]int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	counts := make(map[string]int)
+	for _, p := range f.failedPods {
+		counts[p.Failure.Reason]++
+	}
+	for _, p := range f.givenUp {
+		counts[p.Failure.Reason]++
+	}
+	return counts
+}
+
+//setStrategy records the strategy that matched the pods
+func (f *FailedPods) setStrategy(names []string, strategy string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, name := range names {
+		if p, exists := f.failedPods[name]; exists {
+			p.Strategy = strategy
+			p.Classification = ""
+		}
+	}
+}
+
+//classify records why the pod is not being remediated. Returns true if the classification changed and should be reported
+func (f *FailedPods) classify(name, classification string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	p, exists := f.failedPods[name]
+	if !exists || p.Classification == classification {
+		return false
+	}
+	p.Classification = classification
+	return true
+}
+
+//snapshot returns copies of the failed and given up pods by key
+func (f *FailedPods) snapshot() map[string]FailedPod {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pods := make(map[string]FailedPod, len(f.failedPods)+len(f.givenUp))
+	for name, p := range f.failedPods {
+		pods[name] = *p
+	}
+	for name, p := range f.givenUp {
+		pods[name] = *p
+	}
+	return pods
+}
+
+//isFailing returns true if the pod is currently failing to schedule, including pods that have been given up on
+func (f *FailedPods) isFailing(name string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	_, failed := f.failedPods[name]
+	_, gaveUp := f.givenUp[name]
+	return failed || gaveUp
+}
+
+//getGivenUpPods returns the pods that exceeded the maximum number of remediations
+func (f *FailedPods) getGivenUpPods() []*FailedPod {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	pods := make([]*FailedPod, 0, len(f.givenUp))
+	for _, p := range f.givenUp {
+		pods = append(pods, p)
+	}
+	return pods
+}
+
 func (f *FailedPods) removePod(name string) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
 	glog.V(4).Infof("Removing Pod %s from State if exists", name)
 	delete(f.failedPods, name)
+	delete(f.failures, name)
+	delete(f.givenUp, name)
 }
 
-func (f *FailedPods) incrementRemediations() {
+//incrementRemediations counts a remediation attempt for the given pods.
+//Pods reaching the maximum number of remediations are moved to the given up set and returned
+func (f *FailedPods) incrementRemediations(names []string) (givenUp []*FailedPod) {
 	f.lock.Lock()
 	defer f.lock.Unlock()
-	for _, p := range f.failedPods {
+	for _, name := range names {
+		p, exists := f.failedPods[name]
+		if !exists {
+			continue
+		}
+
 		p.Remediations++
+		if f.maxRemediations > 0 && p.Remediations >= f.maxRemediations {
+			p.GaveUpAt = time.Now()
+			delete(f.failedPods, name)
+			f.givenUp[name] = p
+			givenUp = append(givenUp, p)
+		}
 	}
+	return
 }
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+)
+
+func TestClassifySchedulingMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{
+			message:  "no nodes available to schedule pods",
+			expected: NodeNodesAvailable,
+		},
+		{
+			message:  "pod (foo) failed to fit in any node\nfit failure on node (node-1): PodExceedsFreeCPU\n",
+			expected: InsufficientResources,
+		},
+		{
+			message:  "pod (foo) failed to fit in any node\nfit failure on node (node-1): MatchNodeSelector\n",
+			expected: ConstraintMismatch,
+		},
+		{
+			message:  "pod (foo) failed to fit in any node\nfit failure on node (node-1): MatchNodeSelector\nfit failure on node (node-2): PodExceedsFreeMemory\n",
+			expected: InsufficientResources,
+		},
+		{
+			message:  "0/3 nodes are available: 3 node(s) had taints that the pod didn't tolerate.",
+			expected: ConstraintMismatch,
+		},
+		{
+			message:  "0/3 nodes are available: 3 Insufficient nvidia.com/gpu.",
+			expected: InsufficientResources,
+		},
+		{
+			message:  "pod has unbound immediate PersistentVolumeClaims",
+			expected: UnrecognizedScheduleIssue,
+		},
+		{
+			message:  "0/3 nodes are available: 3 node(s) didn't match pod affinity rules.",
+			expected: UnrecognizedScheduleIssue,
+		},
+	}
+
+	for _, test := range tests {
+		if actual := classifySchedulingMessage(test.message); actual != test.expected {
+			t.Errorf("Message: %q Expected: %s Actual: %s", test.message, test.expected, actual)
+		}
+	}
+
+	if !isRemediableReason(UnknownScheduleIssue) {
+		t.Error("Expected pods with no scheduler event to be remediable")
+	}
+	if isRemediableReason(UnrecognizedScheduleIssue) {
+		t.Error("Expected pods with an unrecognized scheduler message to be blocked")
+	}
+}
+
+func TestGetPodsByCause(t *testing.T) {
+	failed := NewFailedPods(MaxRemediations, time.Hour)
+	now := time.Now()
+
+	failed.setFailure("ns/capacity", ScheduleFailure{Reason: InsufficientResources, Seen: now})
+	failed.setFailure("ns/selector", ScheduleFailure{Reason: ConstraintMismatch, Seen: now})
+	failed.addPod("ns/capacity", &api.Pod{})
+	failed.addPod("ns/selector", &api.Pod{})
+	failed.addPod("ns/unknown", &api.Pod{})
+
+	remediable, blocked := failed.getPodsByCause()
+	if len(remediable) != 2 {
+		t.Errorf("Expected 2 remediable pods. Actual %d", len(remediable))
+	}
+	if len(blocked) != 1 || blocked[0].Failure.Reason != ConstraintMismatch {
+		t.Errorf("Expected selector pod to be blocked. Actual %v", blocked)
+	}
+
+	//Older events must not replace a newer reason
+	failed.setFailure("ns/selector", ScheduleFailure{Reason: InsufficientResources, Seen: now.Add(-time.Minute)})
+	if _, blocked = failed.getPodsByCause(); len(blocked) != 1 {
+		t.Error("Older failure replaced newer failure")
+	}
+
+	failed.setFailure("ns/selector", ScheduleFailure{Reason: InsufficientResources, Seen: now.Add(time.Minute)})
+	if _, blocked = failed.getPodsByCause(); len(blocked) != 0 {
+		t.Error("Newer failure did not replace older failure")
+	}
+}
+
+func TestGiveUpAfterMaxRemediations(t *testing.T) {
+	failed := NewFailedPods(2, 0)
+	pod := &api.Pod{
+		ObjectMeta: api.ObjectMeta{UID: "one"},
+		Spec:       api.PodSpec{NodeName: ""},
+	}
+	failed.addPod("ns/pod", pod)
+
+	if givenUp := failed.incrementRemediations([]string{"ns/pod"}); len(givenUp) != 0 {
+		t.Errorf("Gave up too early. Actual %v", givenUp)
+	}
+
+	//Re-adding during sync must not reset the count
+	failed.addPod("ns/pod", pod)
+	if givenUp := failed.incrementRemediations([]string{"ns/pod"}); len(givenUp) != 1 {
+		t.Errorf("Expected pod to be given up on. Actual %v", givenUp)
+	}
+
+	failed.addPod("ns/pod", pod)
+	if remediable, _ := failed.getPodsByCause(); len(remediable) != 0 {
+		t.Errorf("Given up pod was remediable. Actual %v", remediable)
+	}
+	if len(failed.getGivenUpPods()) != 1 {
+		t.Error("Expected one given up pod")
+	}
+
+	changed := &api.Pod{
+		ObjectMeta: api.ObjectMeta{UID: "one"},
+		Spec:       api.PodSpec{NodeSelector: map[string]string{"foo": "bar"}},
+	}
+	failed.addPod("ns/pod", changed)
+	if remediable, _ := failed.getPodsByCause(); len(remediable) != 1 {
+		t.Error("Changed pod was not readmitted")
+	}
+	if len(failed.getGivenUpPods()) != 0 {
+		t.Error("Changed pod still given up")
+	}
+}
+
+func TestReadmitAfterResetWindow(t *testing.T) {
+	failed := NewFailedPods(1, time.Minute)
+	pod := &api.Pod{ObjectMeta: api.ObjectMeta{UID: "one"}}
+	failed.addPod("ns/pod", pod)
+	givenUp := failed.incrementRemediations([]string{"ns/pod"})
+	if len(givenUp) != 1 {
+		t.Fatalf("Expected pod to be given up on. Actual %v", givenUp)
+	}
+
+	failed.addPod("ns/pod", pod)
+	if len(failed.getGivenUpPods()) != 1 {
+		t.Error("Pod readmitted before reset window")
+	}
+
+	givenUp[0].GaveUpAt = time.Now().Add(-2 * time.Minute)
+	failed.addPod("ns/pod", pod)
+	if len(failed.getGivenUpPods()) != 0 {
+		t.Error("Pod not readmitted after reset window")
+	}
+}
+
+func TestCountByReason(t *testing.T) {
+	failed := NewFailedPods(1, 0)
+	now := time.Now()
+
+	failed.setFailure("ns/capacity", ScheduleFailure{Reason: InsufficientResources, Seen: now})
+	failed.setFailure("ns/selector", ScheduleFailure{Reason: ConstraintMismatch, Seen: now})
+	failed.addPod("ns/capacity", &api.Pod{})
+	failed.addPod("ns/selector", &api.Pod{})
+	failed.addPod("ns/unknown", &api.Pod{})
+	failed.incrementRemediations([]string{"ns/capacity"})
+
+	counts := failed.countByReason()
+	expected := map[string]int{InsufficientResources: 1, ConstraintMismatch: 1, UnknownScheduleIssue: 1}
+	for reason, count := range expected {
+		if counts[reason] != count {
+			t.Errorf("Reason %s Expected: %d Actual: %d", reason, count, counts[reason])
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	failed := NewFailedPods(0, 0)
+	failed.addPod("ns/pod", &api.Pod{})
+
+	tests := []struct {
+		classification string
+		matched        bool
+		changed        bool
+	}{
+		{classification: NoMatchingStrategyReason, changed: true},
+		{classification: NoMatchingStrategyReason, changed: false},
+		{classification: NotRemediableReason + "/" + ConstraintMismatch, changed: true},
+		//Matching a strategy resets the classification
+		{classification: NotRemediableReason + "/" + ConstraintMismatch, matched: true, changed: true},
+	}
+
+	for i, test := range tests {
+		if test.matched {
+			failed.setStrategy([]string{"ns/pod"}, "0")
+		}
+		if changed := failed.classify("ns/pod", test.classification); changed != test.changed {
+			t.Errorf("Test %d: Expected changed %v. Got %v", i, test.changed, changed)
+		}
+	}
+
+	if failed.classify("ns/missing", NoMatchingStrategyReason) {
+		t.Error("Expected no change for an unknown pod")
+	}
+}
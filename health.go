@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+//healthChecker tracks the state reported by /healthz and /readyz
+type healthChecker struct {
+	//cycleTimeout is how long the remediation loop may go without completing a cycle before it is considered stuck
+	cycleTimeout time.Duration
+
+	lock      sync.Mutex
+	synced    bool
+	awsErr    error
+	lastCycle time.Time
+}
+
+func newHealthChecker(cycleTimeout time.Duration) *healthChecker {
+	return &healthChecker{
+		cycleTimeout: cycleTimeout,
+		awsErr:       fmt.Errorf("AWS access not checked yet"),
+		lastCycle:    time.Now(),
+	}
+}
+
+//markSynced records that the informers completed their initial sync
+func (h *healthChecker) markSynced() {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.synced = true
+}
+
+//cycleCompleted records that the remediation loop finished a cycle, including cycles skipped by followers
+func (h *healthChecker) cycleCompleted(now time.Time) {
+	if h == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.lastCycle = now
+}
+
+//checkAWS runs check until it succeeds or stop is closed
+func (h *healthChecker) checkAWS(check func() error, interval time.Duration, stop <-chan struct{}) {
+	for {
+		err := check()
+		h.lock.Lock()
+		h.awsErr = err
+		h.lock.Unlock()
+		if err == nil {
+			glog.Info("AWS credentials and region resolved")
+			return
+		}
+		glog.Warningf("AWS access check failed: %v", err)
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+//live returns an error if the remediation loop has not completed a cycle in time
+func (h *healthChecker) live(now time.Time) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if since := now.Sub(h.lastCycle); since > h.cycleTimeout {
+		return fmt.Errorf("No remediation cycle completed in %v", since)
+	}
+	return nil
+}
+
+//ready returns an error until the informers have synced and AWS access is available
+func (h *healthChecker) ready() error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.synced {
+		return fmt.Errorf("Informers not synced")
+	}
+	return h.awsErr
+}
+
+func writeCheck(w http.ResponseWriter, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
+
+//ServeLive implements /healthz
+func (h *healthChecker) ServeLive(w http.ResponseWriter, r *http.Request) {
+	writeCheck(w, h.live(time.Now()))
+}
+
+//ServeReady implements /readyz
+func (h *healthChecker) ServeReady(w http.ResponseWriter, r *http.Request) {
+	writeCheck(w, h.ready())
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthLive(t *testing.T) {
+	h := newHealthChecker(10 * time.Minute)
+	start := time.Now()
+
+	if err := h.live(start.Add(5 * time.Minute)); err != nil {
+		t.Errorf("Expected live before timeout. Got %v", err)
+	}
+	if err := h.live(start.Add(11 * time.Minute)); err == nil {
+		t.Error("Expected not live without a completed cycle")
+	}
+
+	h.cycleCompleted(start.Add(9 * time.Minute))
+	if err := h.live(start.Add(11 * time.Minute)); err != nil {
+		t.Errorf("Expected live after completed cycle. Got %v", err)
+	}
+}
+
+func TestHealthReady(t *testing.T) {
+	h := newHealthChecker(time.Minute)
+
+	recorder := httptest.NewRecorder()
+	h.ServeReady(recorder, &http.Request{})
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected not ready before sync. Got %d", recorder.Code)
+	}
+
+	h.markSynced()
+	if err := h.ready(); err == nil {
+		t.Error("Expected not ready before AWS check")
+	}
+
+	calls := 0
+	h.checkAWS(func() error {
+		calls++
+		if calls == 1 {
+			return errors.New("no credentials")
+		}
+		return nil
+	}, time.Millisecond, make(chan struct{}))
+	if calls != 2 {
+		t.Errorf("Expected AWS check to retry until success. Calls %d", calls)
+	}
+
+	recorder = httptest.NewRecorder()
+	h.ServeReady(recorder, &http.Request{})
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected ready. Got %d %s", recorder.Code, recorder.Body.String())
+	}
+}
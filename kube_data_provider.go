@@ -2,24 +2,30 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
 	rapi "github.com/jmccarty3/awsScaler/api"
+	"github.com/jmccarty3/awsScaler/api/remediation"
 	"github.com/jmccarty3/awsScaler/api/strategy"
 	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/resource"
 	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/apis/extensions"
 	"k8s.io/kubernetes/pkg/client/cache"
+	"k8s.io/kubernetes/pkg/client/record"
 	kclient "k8s.io/kubernetes/pkg/client/unversioned"
 	"k8s.io/kubernetes/pkg/controller/framework"
 	"k8s.io/kubernetes/pkg/fields"
 	"k8s.io/kubernetes/pkg/labels"
-	"k8s.io/kubernetes/pkg/util/wait"
 )
 
 const (
-	NodeNodesAvailable   = "NoNodesAvailable"
+	NodeNodesAvailable = "NoNodesAvailable"
+	//UnknownScheduleIssue is the reason of pods with no scheduler event yet
 	UnknownScheduleIssue = "UnknownIssue"
 )
 
@@ -27,25 +33,75 @@ type kubeDataProvider struct {
 	client      *kclient.Client
 	failingPods *FailedPods
 	pods        cache.StoreToPodLister
-	strategies  []strategy.RemediationStrategy
-
-	podController *framework.Controller
+	events      cache.Store
+	nodes       cache.StoreToNodeLister
+	inFlight    *remediation.InFlightLedger
+	strategies  []*strategy.RemediationStrategy
+	leader      *leaderElection
+	health      *healthChecker
+	history     *cycleHistory
+	//resolvedGroups are the groups of each strategy as of the last remediation cycle
+	resolvedGroups resolvedGroups
+	//recorder records scaling decisions as events on pods. May be nil
+	recorder record.EventRecorder
+	//auditLog records every capacity change. May be nil
+	auditLog *remediation.AuditLog
+	//statusConfigMap receives a summary of each cycle. May be nil
+	statusConfigMap *statusConfigMap
+
+	//scalingStrategies is nil unless ScalingStrategy resources are enabled
+	scalingStrategies cache.Store
+	//daemonSets is nil unless DaemonSet overhead is enabled
+	daemonSets cache.Store
+	//instanceGroups maps nodes to their autoscaling group. In flight capacity is only released once its pods schedule while nil
+	instanceGroups instanceGroupLookup
+	//learnCapacity uses the allocatable resources of registered nodes as the capacity of new nodes of their group
+	learnCapacity    bool
+	observedCapacity map[string]observedNode
+	observedLock     sync.Mutex
+
+	//configStrategies and customStrategies are combined into strategies at the start of the next remediation cycle once changed
+	configStrategies  []*strategy.RemediationStrategy
+	customStrategies  map[string]*customStrategy
+	strategiesChanged bool
+	strategyLock      sync.Mutex
+
+	podController             *framework.Controller
+	eventController           *framework.Controller
+	nodeController            *framework.Controller
+	scalingStrategyController *framework.Controller
+	daemonSetController       *framework.Controller
 }
 
 func newKubeDataProvider(client *kclient.Client) *kubeDataProvider {
 	c := &kubeDataProvider{
 		client:      client,
-		failingPods: NewFailedPods(),
+		failingPods: NewFailedPods(*argMaxRemediations, time.Duration(*argRemediationResetMinutes)*time.Minute),
+		inFlight:    remediation.NewInFlightLedger(time.Duration(*argInFlightTimeoutMinutes) * time.Minute),
+		history:     newCycleHistory(*argStatusCycles),
 	}
 
 	c.createPodController()
+	c.createEventController()
+	c.createNodeController()
+	if *argScalingStrategies {
+		c.createScalingStrategyController()
+	}
+	if *argDaemonSetOverhead {
+		if client.ExtensionsClient == nil {
+			glog.Warning("Extensions API unavailable. DaemonSet overhead will not be subtracted from new nodes")
+		} else {
+			c.createDaemonSetController()
+		}
+	}
 	return c
 }
 
 func createEventListWatcher(client *kclient.Client) *cache.ListWatch {
-	//s, _ := fields.ParseSelector("involvedObject.kind=Pod")
-	//s, _ := fields.ParseSelector("source=scheduler")
-	s := fields.Everything()
+	s := fields.Set{
+		"involvedObject.kind": "Pod",
+		"reason":              FailedSchedulingReason,
+	}.AsSelector()
 	return cache.NewListWatchFromClient(client, "events", api.NamespaceAll, s)
 }
 
@@ -53,6 +109,14 @@ func createPodListWatcher(client *kclient.Client) *cache.ListWatch {
 	return cache.NewListWatchFromClient(client, "pods", api.NamespaceAll, fields.Everything())
 }
 
+func createNodeListWatcher(client *kclient.Client) *cache.ListWatch {
+	return cache.NewListWatchFromClient(client, "nodes", api.NamespaceAll, fields.Everything())
+}
+
+func createDaemonSetListWatcher(client *kclient.Client) *cache.ListWatch {
+	return cache.NewListWatchFromClient(client.ExtensionsClient, "daemonsets", api.NamespaceAll, fields.Everything())
+}
+
 func printEvent(e *api.Event) string {
 	return fmt.Sprintf("Name: %s Reason: %s Source: %s Count: %d Message: %s ", e.Name, e.Reason, e.Source, e.Count, e.Message)
 }
@@ -95,6 +159,16 @@ func (k *kubeDataProvider) syncFailingPods() {
 			k.failingPods.removePod(key)
 		}
 	}
+	for _, failed := range k.failingPods.getGivenUpPods() {
+		if p, exists, _ := k.pods.Get(failed.Pod); !exists || isPodStatusFine(p.(*api.Pod)) {
+			key, _ := cache.MetaNamespaceKeyFunc(failed.Pod)
+			k.failingPods.removePod(key)
+		}
+	}
+	k.failingPods.pruneFailures(func(key string) bool {
+		p, exists, _ := k.pods.Store.GetByKey(key)
+		return exists && !isPodStatusFine(p.(*api.Pod))
+	})
 	glog.V(4).Info("Finished Recolomation") // TODO: clarify this statement
 }
 
@@ -117,6 +191,175 @@ func (k *kubeDataProvider) createPodController() {
 	)
 }
 
+func (k *kubeDataProvider) recordSchedulingEvent(obj interface{}) {
+	e, ok := obj.(*api.Event)
+	if !ok {
+		return
+	}
+
+	key, ok := podKeyForEvent(e)
+	if !ok {
+		return
+	}
+	glog.V(5).Info("Scheduling event: ", printEvent(e))
+
+	k.failingPods.setFailure(key, ScheduleFailure{
+		Reason:  classifySchedulingMessage(e.Message),
+		Message: e.Message,
+		Seen:    e.LastTimestamp.Time,
+	})
+}
+
+func (k *kubeDataProvider) createEventController() {
+	k.events, k.eventController = framework.NewInformer(
+		createEventListWatcher(k.client),
+		&api.Event{},
+		0,
+		framework.ResourceEventHandlerFuncs{
+			AddFunc: k.recordSchedulingEvent,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				k.recordSchedulingEvent(newObj)
+			},
+		},
+	)
+}
+
+func (k *kubeDataProvider) createNodeController() {
+	k.nodes.Store, k.nodeController = framework.NewInformer(
+		createNodeListWatcher(k.client),
+		&api.Node{},
+		0,
+		framework.ResourceEventHandlerFuncs{},
+	)
+}
+
+func (k *kubeDataProvider) createDaemonSetController() {
+	k.daemonSets, k.daemonSetController = framework.NewInformer(
+		createDaemonSetListWatcher(k.client),
+		&extensions.DaemonSet{},
+		0,
+		framework.ResourceEventHandlerFuncs{},
+	)
+}
+
+func (k *kubeDataProvider) createScalingStrategyController() {
+	k.scalingStrategies, k.scalingStrategyController = framework.NewInformer(
+		createScalingStrategyListWatcher(k.client),
+		&ScalingStrategy{},
+		0,
+		framework.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				k.syncScalingStrategies()
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				k.syncScalingStrategies()
+			},
+			DeleteFunc: func(oldObj interface{}) {
+				k.syncScalingStrategies()
+			},
+		},
+	)
+}
+
+//instanceIDForNode returns the cloud instance id backing a node. ProviderID is of the form aws:///zone/instance-id
+func instanceIDForNode(node *api.Node) string {
+	if node.Spec.ProviderID != "" {
+		return node.Spec.ProviderID[strings.LastIndex(node.Spec.ProviderID, "/")+1:]
+	}
+	return node.Spec.ExternalID
+}
+
+//IsRegistered returns true if a node backed by the instance is registered with the cluster
+func (k *kubeDataProvider) IsRegistered(instanceID string) bool {
+	for _, obj := range k.nodes.Store.List() {
+		if instanceIDForNode(obj.(*api.Node)) == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+//labelsForInstances returns the labels of the first registered node backed by one of the instances, or nil if none registered
+func (k *kubeDataProvider) labelsForInstances(instanceIDs []string) labels.Set {
+	wanted := make(map[string]bool, len(instanceIDs))
+	for _, id := range instanceIDs {
+		wanted[id] = true
+	}
+
+	for _, obj := range k.nodes.Store.List() {
+		node := obj.(*api.Node)
+		if wanted[instanceIDForNode(node)] {
+			return labels.Set(node.Labels)
+		}
+	}
+	return nil
+}
+
+//daemonSetRunsOn returns true if the DaemonSet schedules a pod onto nodes with the labels.
+//Without labels only DaemonSets running on every node match
+func daemonSetRunsOn(ds *extensions.DaemonSet, nodeLabels labels.Set) bool {
+	selector := ds.Spec.Template.Spec.NodeSelector
+	if len(selector) == 0 {
+		return true
+	}
+	return nodeLabels != nil && labels.SelectorFromSet(labels.Set(selector)).Matches(nodeLabels)
+}
+
+//DaemonSetOverhead returns the requests of the DaemonSet pods that will run on a new node joining the instances.
+//The labels of the instances' registered nodes decide which DaemonSets match
+func (k *kubeDataProvider) DaemonSetOverhead(instanceIDs []string) *rapi.Resources {
+	overhead := &rapi.Resources{}
+	if k.daemonSets == nil {
+		return overhead
+	}
+
+	nodeLabels := k.labelsForInstances(instanceIDs)
+	for _, obj := range k.daemonSets.List() {
+		ds := obj.(*extensions.DaemonSet)
+		if daemonSetRunsOn(ds, nodeLabels) {
+			overhead.Add(getNeededResources([]*api.Pod{{Spec: ds.Spec.Template.Spec}}))
+		}
+	}
+	return overhead
+}
+
+//resolveInFlight releases in flight capacity no longer needed and reports capacity that never arrived
+func (k *kubeDataProvider) resolveInFlight() {
+	for _, released := range k.inFlight.ReleaseScheduled(k.failingPods.isFailing) {
+		glog.V(2).Infof("Releasing %d instances requested from group %s. Pods are no longer pending", released.Instances, released.Group)
+	}
+	k.releaseRegistered()
+
+	for _, failed := range k.inFlight.Expire(time.Now()) {
+		remediationErrors.WithLabelValues(ErrorInFlightExpired).Inc()
+		glog.Errorf("%d instances requested from group %s at %v did not register in time. Pods: %v", failed.Instances, failed.Group, failed.Requested, failed.Pods)
+	}
+}
+
+//releaseRegistered releases in flight capacity of groups with as many registered nodes as they were scaled to.
+//Runs every cycle as remediation is skipped while in flight capacity covers the pending pods
+func (k *kubeDataProvider) releaseRegistered() {
+	if k.instanceGroups == nil || len(k.inFlight.Entries()) == 0 {
+		return
+	}
+
+	_, groups, err := k.nodeGroups()
+	if err != nil {
+		glog.Warningf("Unable to map nodes to autoscaling groups. In flight capacity is released once its pods schedule: %v", err)
+		return
+	}
+
+	registered := make(map[string]int)
+	for _, group := range groups {
+		registered[group]++
+	}
+	for group, count := range registered {
+		for _, released := range k.inFlight.Release(group, count) {
+			glog.Infof("%d instances requested from group %s at %v have registered", released.Instances, released.Group, released.Requested)
+		}
+	}
+}
+
 func getResourceMem(mem *api.ResourceRequirements) int64 {
 	if (*mem.Limits.Cpu() != resource.Quantity{} && mem.Limits.Memory().Value() > 0) {
 		return mem.Limits.Memory().Value() / (1024 * 1024) // Memory is returned as the full value. We want it truncated to Megabytes
@@ -131,72 +374,407 @@ func getResourceCPU(cpu *api.ResourceRequirements) int64 {
 	return cpu.Requests.Cpu().MilliValue()
 }
 
-func (k *kubeDataProvider) getNeededResources(pods []*api.Pod) *rapi.Resources {
-	var cpu, mem int64
+//legacyGPUResource is the name GPUs were requested by before device plugins
+const legacyGPUResource = "alpha.kubernetes.io/nvidia-gpu"
+
+//getResourceValue returns the value of the named resource. Limits are preferred like they are for CPU and memory
+func getResourceValue(requirements *api.ResourceRequirements, name api.ResourceName) int64 {
+	if q, exists := requirements.Limits[name]; exists && q.Value() > 0 {
+		return q.Value()
+	}
+	q := requirements.Requests[name]
+	return q.Value()
+}
+
+//getExtendedResources adds the extended resources of the container, such as GPUs, to needed
+func getExtendedResources(requirements *api.ResourceRequirements, needed *rapi.Resources) {
+	names := make(map[api.ResourceName]bool)
+	for name := range requirements.Limits {
+		names[name] = true
+	}
+	for name := range requirements.Requests {
+		names[name] = true
+	}
+
+	for name := range names {
+		if neededName, extended := extendedResourceName(name); extended {
+			needed.Set(neededName, needed.Get(neededName)+getResourceValue(requirements, name))
+		}
+	}
+}
+
+//extendedResourceName returns the name an extended resource is tracked by. GPUs requested by their legacy name count as nvidia.com/gpu
+func extendedResourceName(name api.ResourceName) (string, bool) {
+	if !strings.Contains(string(name), "/") {
+		return "", false
+	}
+	if name == legacyGPUResource {
+		return rapi.ResourceGPU, true
+	}
+	return string(name), true
+}
+
+func getNeededResources(pods []*api.Pod) *rapi.Resources {
+	needed := &rapi.Resources{}
 	for _, pod := range pods {
+		needed.Pods++
 		for _, c := range pod.Spec.Containers {
-			cpu += getResourceCPU(&c.Resources)
-			mem += getResourceMem(&c.Resources)
+			needed.CPU += getResourceCPU(&c.Resources)
+			needed.MemMB += getResourceMem(&c.Resources)
+			needed.EphemeralStorageMB += getResourceValue(&c.Resources, rapi.ResourceEphemeralStorage) / (1024 * 1024)
+			getExtendedResources(&c.Resources, needed)
 		}
 	}
+	return needed
+}
+
+//podDemands returns the capacity needed by each pod without capacity already in flight
+func (k *kubeDataProvider) podDemands(pods []*api.Pod, keys []string) []remediation.PodDemand {
+	var demands []remediation.PodDemand
+	for i, pod := range pods {
+		if k.inFlight.HasPendingFor([]string{keys[i]}) {
+			continue
+		}
+		demands = append(demands, remediation.PodDemand{
+			Pod:       keys[i],
+			Resources: *getNeededResources([]*api.Pod{pod}),
+		})
+	}
+	return demands
+}
+
+//SetStrategies replaces the strategies from the configuration. The change takes effect at the start of the next remediation cycle
+func (k *kubeDataProvider) SetStrategies(strategies []strategy.RemediationStrategy) {
+	k.strategyLock.Lock()
+	defer k.strategyLock.Unlock()
 
-	return &rapi.Resources{
-		CPU:   cpu,
-		MemMB: mem,
+	k.configStrategies = make([]*strategy.RemediationStrategy, len(strategies))
+	for i := range strategies {
+		k.configStrategies[i] = &strategies[i]
 	}
+	k.strategiesChanged = true
+}
+
+//swapStrategies applies strategies changed since the last cycle.
+//ScalingStrategy resources come first so they take precedence over the catch all strategies usually found in the configuration
+func (k *kubeDataProvider) swapStrategies() {
+	k.strategyLock.Lock()
+	defer k.strategyLock.Unlock()
+
+	if k.strategiesChanged {
+		strategies := append(orderedCustomStrategies(k.customStrategies), k.configStrategies...)
+		glog.Infof("Switching from %d to %d strategies", len(k.strategies), len(strategies))
+		carryCooldowns(k.strategies, strategies)
+		k.strategies = strategies
+		k.strategiesChanged = false
+		k.resolvedGroups.invalidate()
+	}
+}
+
+//carryCooldowns copies the cooldowns of the strategies being replaced to the new ones so a reload can not scale up early.
+//ScalingStrategies are matched by name, strategies from the configuration by position and groups by name
+func carryCooldowns(previous, next []*strategy.RemediationStrategy) {
+	lastScaleUp := make(map[string]time.Time)
+	groups := make(map[string]time.Time)
+	for _, s := range previous {
+		for name, scaled := range s.LastScaledGroups() {
+			if scaled.After(groups[name]) {
+				groups[name] = scaled
+			}
+		}
+	}
+	for key, s := range cooldownKeys(previous) {
+		lastScaleUp[key] = s.LastScaleUp()
+	}
+
+	for key, s := range cooldownKeys(next) {
+		s.RestoreCooldowns(lastScaleUp[key], groups)
+	}
+}
+
+//cooldownKeys identifies strategies across reloads. Unnamed strategies come from the configuration and are identified by position within it
+func cooldownKeys(strategies []*strategy.RemediationStrategy) map[string]*strategy.RemediationStrategy {
+	keys := make(map[string]*strategy.RemediationStrategy, len(strategies))
+	unnamed := 0
+	for _, s := range strategies {
+		if s.Name != "" {
+			keys["name/"+s.Name] = s
+			continue
+		}
+		keys["config/"+strconv.Itoa(unnamed)] = s
+		unnamed++
+	}
+	return keys
 }
 
 // remediateFailingPods applies its remediation strategies to the currently failing pods
-func (k *kubeDataProvider) remediateFailingPods() {
+func (k *kubeDataProvider) remediateFailingPods(stop <-chan struct{}) {
+	k.swapStrategies()
+
+	if !k.leader.IsLeader() {
+		glog.V(2).Info("Not the leader. Skipping remediation")
+		return
+	}
+
+	remediationCycles.Inc()
 	k.syncFailingPods()
+	k.resolveInFlight()
+	k.recordFailingPods()
+
+	results := map[string]*strategyResult{}
+	cycle := CycleStatus{Started: time.Now(), Matched: map[string]int{}}
+	defer func() {
+		k.reportScalingStrategies(results, time.Now())
+		k.history.add(cycle)
+		//A stopped scaler is handing over to another replica
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		k.resolveStrategyGroups(time.Now())
+		k.writeStatusConfigMap(cycle)
+	}()
 
 	glog.V(4).Info("StateGraph:", k.failingPods.failedPods)
 
 	//TODO: Move this logic
-	remainingPodsToRemediate := k.failingPods.getPods()
+	remainingPodsToRemediate, blockedPods := k.failingPods.getPodsByCause()
+	cycle.Remediable = len(remainingPodsToRemediate)
+	for _, blocked := range blockedPods {
+		key, _ := cache.MetaNamespaceKeyFunc(blocked.Pod)
+		glog.Warningf("Pod %s can not be fixed by scaling. Reason: %s Message: %s", key, blocked.Failure.Reason, blocked.Failure.Message)
+		if k.failingPods.classify(key, NotRemediableReason+"/"+blocked.Failure.Reason) {
+			recordPodEvents(k.recorder, []*api.Pod{blocked.Pod}, api.EventTypeWarning, NotRemediableReason, "Adding nodes will not help this pod schedule: %s", blocked.Failure.Message)
+		}
+	}
+	for _, givenUp := range k.failingPods.getGivenUpPods() {
+		key, _ := cache.MetaNamespaceKeyFunc(givenUp.Pod)
+		glog.Warningf("Pod %s still pending after %d remediations. Not remediating since %v", key, givenUp.Remediations, givenUp.GaveUpAt)
+	}
+
 	if len(remainingPodsToRemediate) > 0 {
 		glog.Warning("Nodes in need of remediation. Requesting response")
+		k.learnNodeCapacity()
 
 		var podsCanFix []*api.Pod
+		var remediatedPods []string
+
+		for i, stratgy := range k.strategies {
+			select {
+			case <-stop:
+				glog.Warning("Stopping remediation before running remaining strategies")
+				remediationErrors.WithLabelValues(ErrorCancelled).Inc()
+				return
+			default:
+			}
 
-		for _, stratgy := range k.strategies {
 			podsCanFix, remainingPodsToRemediate = stratgy.FilterPods(remainingPodsToRemediate)
+			result := &strategyResult{matched: len(podsCanFix)}
+			failingPodsByStrategy.WithLabelValues(strategyLabel(i, stratgy)).Set(float64(len(podsCanFix)))
+			cycle.Matched[strategyLabel(i, stratgy)] = len(podsCanFix)
+			if stratgy.Name != "" {
+				results[stratgy.Name] = result
+			}
+
+			if cooling, until := stratgy.InCooldown(time.Now()); cooling && len(podsCanFix) > 0 {
+				glog.Infof("Strategy %d in cooldown until %v. Skipping %d pods", i, until, len(podsCanFix))
+				continue
+			}
 
 			if len(podsCanFix) > 0 {
-				resources := k.getNeededResources(podsCanFix)
-				glog.Infof("Missing Resources. CPU: %d  MemMB: %d Pod Count: %d", resources.CPU, resources.MemMB, len(k.failingPods.getPods()))
-				if unresolved, err := stratgy.DoRemediation(resources); *unresolved == rapi.EmptyResources {
+				podKeys := make([]string, len(podsCanFix))
+				for i, pod := range podsCanFix {
+					podKeys[i], _ = cache.MetaNamespaceKeyFunc(pod)
+				}
+				k.failingPods.setStrategy(podKeys, strategyLabel(i, stratgy))
+				resources := getNeededResources(podsCanFix)
+				glog.Infof("Missing Resources: %v Pod Count: %d", *resources, len(k.failingPods.getPods()))
+				if k.inFlight.HasPendingFor(podKeys) {
+					inFlight := k.inFlight.PendingFor(podKeys)
+					resources.Remove(inFlight)
+					glog.Infof("Capacity already in flight: %v", *inFlight)
+					if resources.IsEmpty() {
+						glog.Info("In flight capacity covers all missing resources. Skipping remediation")
+						continue
+					}
+				}
+
+				req := &remediation.Request{
+					Strategy: strategyLabel(i, stratgy),
+					Pods:     podKeys,
+					Ledger:   k.inFlight,
+					Nodes:    k,
+					Overhead: k,
+					Observed: k,
+					Stop:     stop,
+					DryRun:   *argDryRun || stratgy.DryRun,
+					AuditLog: k.auditLog,
+					Demands:  k.podDemands(podsCanFix, podKeys),
+				}
+				if !req.DryRun {
+					remediatedPods = append(remediatedPods, podKeys...)
+				}
+				//Remediators remove what they provide from resources, including its extended resources, so record a copy
+				requested := resources.Copy()
+				unresolved, err := stratgy.DoRemediation(resources, req)
+				if unresolved.IsEmpty() {
 					glog.Info("Remediation request successful")
 				} else {
 					glog.Errorf("Remediation failed. Error: %v Leftover Resources: %v", err, unresolved)
 				}
+				result.attempted, result.scaledUp, result.err = true, req.ScaledUp(), err
+				k.recordRemediation(strategyLabel(i, stratgy), req, unresolved)
+				cycle.Runs = append(cycle.Runs, newStrategyRun(strategyLabel(i, stratgy), requested, unresolved, req, err))
+				if req.DryRun {
+					for _, planned := range req.ScaleUps {
+						glog.Infof("Dry run plan for strategy %d: set group %s capacity from %d to %d (+%d) for %d pods", i, planned.Group, planned.FromSize, planned.ToSize, planned.Instances, len(podKeys))
+					}
+				}
+				k.recordRemediationEvents(podsCanFix, req, err)
 			}
 		}
 
+		failingPodsByStrategy.WithLabelValues(NoStrategyLabel).Set(float64(len(remainingPodsToRemediate)))
+		cycle.Unmatched = len(remainingPodsToRemediate)
 		if len(remainingPodsToRemediate) > 0 {
 			glog.Warningf("Unable to find strategy for %d pods\n", len(remainingPodsToRemediate))
+			remediationErrors.WithLabelValues(ErrorNoStrategy).Inc()
+			var newlyUnmatched []*api.Pod
+			for _, pod := range remainingPodsToRemediate {
+				if key, _ := cache.MetaNamespaceKeyFunc(pod); k.failingPods.classify(key, NoMatchingStrategyReason) {
+					newlyUnmatched = append(newlyUnmatched, pod)
+				}
+			}
+			recordPodEvents(k.recorder, newlyUnmatched, api.EventTypeWarning, NoMatchingStrategyReason, "No scaling strategy matches this pod")
+		}
+		for _, givenUp := range k.failingPods.incrementRemediations(remediatedPods) {
+			key, _ := cache.MetaNamespaceKeyFunc(givenUp.Pod)
+			glog.Errorf("Giving up on pod %s after %d remediations. Last reason: %s", key, givenUp.Remediations, givenUp.Failure.Reason)
+			recordPodEvents(k.recorder, []*api.Pod{givenUp.Pod}, api.EventTypeWarning, GaveUpAfterRemediationsReason, "Gave up adding capacity after %d remediations", givenUp.Remediations)
+		}
+	}
+}
+
+//strategyLabel identifies a strategy in metrics. Strategies from the configuration are identified by position
+func strategyLabel(i int, s *strategy.RemediationStrategy) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return strconv.Itoa(i)
+}
+
+//recordFailingPods resets the per cycle metrics and records the currently failing pods
+func (k *kubeDataProvider) recordFailingPods() {
+	failingPodsByReason.Reset()
+	failingPodsByStrategy.Reset()
+	unresolvedCPU.Reset()
+	unresolvedMemMB.Reset()
+	for reason, count := range k.failingPods.countByReason() {
+		failingPodsByReason.WithLabelValues(reason).Set(float64(count))
+	}
+}
+
+//recordRemediation records the capacity requested by a strategy and what it was unable to remediate
+func (k *kubeDataProvider) recordRemediation(label string, req *remediation.Request, unresolved *rapi.Resources) {
+	unresolvedCPU.WithLabelValues(label).Set(float64(unresolved.CPU))
+	unresolvedMemMB.WithLabelValues(label).Set(float64(unresolved.MemMB))
+	if !unresolved.IsEmpty() {
+		remediationErrors.WithLabelValues(ErrorUnresolved).Inc()
+	}
+	if req.DryRun {
+		return
+	}
+	for _, scaleUp := range req.ScaleUps {
+		scaleUpRequests.WithLabelValues(scaleUp.Group).Inc()
+		instancesRequested.WithLabelValues(scaleUp.Group).Add(float64(scaleUp.Instances))
+	}
+}
+
+//hasSynced returns true once all informers needed to remediate completed their initial list.
+//DaemonSets only refine the capacity of new nodes so remediation does not wait for them
+func (k *kubeDataProvider) hasSynced() bool {
+	return k.podController.HasSynced() && k.eventController.HasSynced() && k.nodeController.HasSynced() &&
+		(k.scalingStrategyController == nil || k.scalingStrategyController.HasSynced())
+}
+
+//recordRemediationEvents records the scale ups a remediation requested, or the reason it failed, on the pods it was for
+func (k *kubeDataProvider) recordRemediationEvents(pods []*api.Pod, req *remediation.Request, err error) {
+	for _, scaleUp := range req.ScaleUps {
+		reason := TriggeredScaleUpReason
+		if req.DryRun {
+			reason = DryRunScaleUpReason
 		}
-		k.failingPods.incrementRemediations()
+		scaledFor := pods
+		if scaleUp.Pods != nil {
+			scaledFor = podsWithKeys(pods, scaleUp.Pods)
+		}
+		recordPodEvents(k.recorder, scaledFor, api.EventTypeNormal, reason, "Group %s +%d (%d -> %d)", scaleUp.Group, scaleUp.Instances, scaleUp.FromSize, scaleUp.ToSize)
+	}
+	for _, tooLarge := range req.TooLarge {
+		recordPodEvents(k.recorder, podsWithKeys(pods, tooLarge.Pods), api.EventTypeWarning, TooLargeForInstanceTypeReason,
+			"Pod does not fit on an empty %s instance of group %s", tooLarge.InstanceType, tooLarge.Group)
+	}
+	if err != nil {
+		recordPodEvents(k.recorder, pods, api.EventTypeWarning, FailedScaleUpReason, "%v", err)
 	}
 }
 
-func (k *kubeDataProvider) Run(strategies []strategy.RemediationStrategy) {
+//podsWithKeys returns the pods with one of the given keys
+func podsWithKeys(pods []*api.Pod, keys []string) []*api.Pod {
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
 
-	go k.podController.Run(wait.NeverStop)
+	var matching []*api.Pod
+	for _, pod := range pods {
+		if key, _ := cache.MetaNamespaceKeyFunc(pod); wanted[key] {
+			matching = append(matching, pod)
+		}
+	}
+	return matching
+}
+
+//Run starts the informers and remediates failing pods on a timer until stop is closed.
+//A remediation in progress when stop closes is aborted between remediation steps
+func (k *kubeDataProvider) Run(stop <-chan struct{}) {
+	go k.podController.Run(stop)
+	go k.eventController.Run(stop)
+	go k.nodeController.Run(stop)
+	if k.scalingStrategyController != nil {
+		go k.scalingStrategyController.Run(stop)
+	}
+	if k.daemonSetController != nil {
+		go k.daemonSetController.Run(stop)
+	}
 	glog.Info("Waiting for PodContoller sync")
-	for k.podController.HasSynced() == false {
-		time.Sleep(1 * time.Second)
+	for !k.hasSynced() {
+		select {
+		case <-stop:
+			return
+		case <-time.After(1 * time.Second):
+		}
 	}
 	glog.Info("Initial PodController sync complete")
-	k.strategies = strategies
+	k.health.markSynced()
+	if k.daemonSetController != nil && !k.daemonSetController.HasSynced() {
+		glog.Warning("DaemonSets have not synced. DaemonSet overhead will not be subtracted from new nodes until they do. The service account needs list and watch on daemonsets")
+	}
 
 	if *argSyncNow {
-		k.remediateFailingPods()
+		k.remediateFailingPods(stop)
+		k.health.cycleCompleted(time.Now())
 	}
 
 	for {
-		time.Sleep(time.Minute * time.Duration(*argRemediationMinutes))
-		k.remediateFailingPods()
-
+		select {
+		case <-stop:
+			glog.Info("Remediation loop stopped")
+			return
+		case <-time.After(time.Minute * time.Duration(*argRemediationMinutes)):
+			k.remediateFailingPods(stop)
+			k.health.cycleCompleted(time.Now())
+		}
 	}
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	rapi "github.com/jmccarty3/awsScaler/api"
+	"github.com/jmccarty3/awsScaler/api/remediation"
+	"github.com/jmccarty3/awsScaler/api/strategy"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+	"k8s.io/kubernetes/pkg/client/cache"
+)
+
+func makeResourcePod(requests api.ResourceList) *api.Pod {
+	return &api.Pod{
+		Spec: api.PodSpec{
+			Containers: []api.Container{{Resources: api.ResourceRequirements{Requests: requests}}},
+		},
+	}
+}
+
+func TestGetNeededResources(t *testing.T) {
+	pods := []*api.Pod{
+		makeResourcePod(api.ResourceList{
+			api.ResourceCPU:    resource.MustParse("500m"),
+			api.ResourceMemory: resource.MustParse("1Gi"),
+			rapi.ResourceGPU:   resource.MustParse("2"),
+		}),
+		makeResourcePod(api.ResourceList{
+			api.ResourceCPU:               resource.MustParse("1"),
+			legacyGPUResource:             resource.MustParse("1"),
+			rapi.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+			"example.com/foo":             resource.MustParse("3"),
+		}),
+	}
+
+	expected := &rapi.Resources{
+		CPU:                1500,
+		MemMB:              1024,
+		Pods:               2,
+		EphemeralStorageMB: 2048,
+		Extended:           map[string]int64{rapi.ResourceGPU: 3, "example.com/foo": 3},
+	}
+	if actual := getNeededResources(pods); !actual.Equal(expected) {
+		t.Errorf("Expected %v. Got %v", expected, actual)
+	}
+}
+
+func makeDaemonSet(name, cpu string, nodeSelector map[string]string) *extensions.DaemonSet {
+	pod := makeResourcePod(api.ResourceList{api.ResourceCPU: resource.MustParse(cpu)})
+	pod.Spec.NodeSelector = nodeSelector
+	return &extensions.DaemonSet{
+		ObjectMeta: api.ObjectMeta{Namespace: "kube-system", Name: name},
+		Spec: extensions.DaemonSetSpec{
+			Template: api.PodTemplateSpec{Spec: pod.Spec},
+		},
+	}
+}
+
+func TestDaemonSetOverhead(t *testing.T) {
+	k := &kubeDataProvider{daemonSets: cache.NewStore(cache.MetaNamespaceKeyFunc)}
+	k.nodes.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	k.nodes.Store.Add(&api.Node{
+		ObjectMeta: api.ObjectMeta{Name: "gpu-node", Labels: map[string]string{"usage": "gpu"}},
+		Spec:       api.NodeSpec{ProviderID: "aws:///us-east-1a/i-gpu"},
+	})
+	k.daemonSets.Add(makeDaemonSet("logging", "100m", nil))
+	k.daemonSets.Add(makeDaemonSet("gpu-driver", "200m", map[string]string{"usage": "gpu"}))
+	k.daemonSets.Add(makeDaemonSet("ingress", "400m", map[string]string{"usage": "edge"}))
+
+	tests := []struct {
+		instanceIDs []string
+		expected    rapi.Resources
+	}{
+		{
+			instanceIDs: []string{"i-other", "i-gpu"},
+			expected:    rapi.Resources{CPU: 300, Pods: 2},
+		},
+		{
+			//A group without registered nodes only runs DaemonSets without a node selector
+			instanceIDs: []string{"i-new"},
+			expected:    rapi.Resources{CPU: 100, Pods: 1},
+		},
+	}
+
+	for i, test := range tests {
+		if actual := k.DaemonSetOverhead(test.instanceIDs); !actual.Equal(&test.expected) {
+			t.Errorf("Test %d: Expected %v. Got %v", i, test.expected, actual)
+		}
+	}
+
+	k.daemonSets = nil
+	if actual := k.DaemonSetOverhead([]string{"i-gpu"}); !actual.IsEmpty() {
+		t.Errorf("Expected no overhead without DaemonSets. Got %v", actual)
+	}
+}
+
+func TestResolveInFlightReleasesRegistered(t *testing.T) {
+	k := &kubeDataProvider{
+		failingPods:    NewFailedPods(0, 0),
+		inFlight:       remediation.NewInFlightLedger(time.Hour),
+		instanceGroups: &fakeInstanceGroups{groups: map[string]string{"i-one": "workers", "i-two": "workers"}},
+	}
+	k.nodes.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	k.failingPods.addPod("ns/a", &api.Pod{})
+	k.inFlight.Add(&remediation.InFlight{Group: "workers", Instances: 1, TargetSize: 2, Pods: []string{"ns/a"}, Requested: time.Now()})
+
+	k.nodes.Store.Add(makeCapacityNode("i-one", "m4.xlarge", "4", time.Now()))
+	k.resolveInFlight()
+	if len(k.inFlight.Entries()) != 1 {
+		t.Fatal("Expected capacity to stay in flight until the group reaches its target size")
+	}
+
+	//The pod is still pending so only registration releases the capacity
+	k.nodes.Store.Add(makeCapacityNode("i-two", "m4.xlarge", "4", time.Now()))
+	k.resolveInFlight()
+	if remaining := k.inFlight.Entries(); len(remaining) != 0 {
+		t.Errorf("Expected registered capacity to be released. Remaining %v", remaining)
+	}
+}
+
+func TestCarryCooldowns(t *testing.T) {
+	var cooldownConfig = `
+strategies:
+- cooldown: 5m
+  remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+      cooldown: 10m
+`
+	previous, err := parseConfig([]byte(cooldownConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing config. %v", err)
+	}
+	next, err := parseConfig([]byte(cooldownConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing config. %v", err)
+	}
+
+	scaled := time.Now().Add(-time.Minute)
+	previous.Strategies[0].RestoreCooldowns(scaled, map[string]time.Time{"foo": scaled})
+	carryCooldowns([]*strategy.RemediationStrategy{&previous.Strategies[0]}, []*strategy.RemediationStrategy{&next.Strategies[0]})
+
+	if cooling, _ := next.Strategies[0].InCooldown(time.Now()); !cooling {
+		t.Error("Expected the reloaded strategy to keep its cooldown")
+	}
+	if last := next.Strategies[0].LastScaledGroups()["foo"]; !last.Equal(scaled) {
+		t.Errorf("Expected group foo last scaled at %v. Got %v", scaled, last)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/leaderelection"
+	"k8s.io/kubernetes/pkg/client/record"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+)
+
+//leaderElection tracks whether this replica currently holds the scaler lease
+type leaderElection struct {
+	elector *leaderelection.LeaderElector
+
+	leading bool
+	lock    sync.Mutex
+}
+
+//newLeaderElection creates a leader election using a lease stored on the namespace/name Endpoints object
+func newLeaderElection(client *kclient.Client, recorder record.EventRecorder, namespace, name string) (*leaderElection, error) {
+	identity, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	l := &leaderElection{}
+	l.elector, err = leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		EndpointsMeta: api.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Identity:      identity,
+		Client:        client,
+		EventRecorder: recorder,
+		LeaseDuration: *argLeaderElectLeaseDuration,
+		RenewDeadline: *argLeaderElectRenewDeadline,
+		RetryPeriod:   *argLeaderElectRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(stop <-chan struct{}) {
+				glog.Infof("%s became the leader for %s/%s", identity, namespace, name)
+				l.setLeading(true)
+			},
+			OnStoppedLeading: func() {
+				glog.Warningf("%s stopped leading %s/%s", identity, namespace, name)
+				l.setLeading(false)
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *leaderElection) setLeading(leading bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.leading = leading
+}
+
+//IsLeader returns true if this replica holds the lease. A nil leaderElection is always the leader
+func (l *leaderElection) IsLeader() bool {
+	if l == nil {
+		return true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.leading
+}
+
+//Run campaigns for leadership, campaigning again whenever leadership is lost until stop is closed
+func (l *leaderElection) Run(stop <-chan struct{}) {
+	for {
+		l.elector.Run()
+		select {
+		case <-stop:
+			return
+		case <-time.After(*argLeaderElectRetryPeriod):
+			glog.Info("Leader election ended. Campaigning again")
+		}
+	}
+}
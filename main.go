@@ -3,15 +3,22 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io/ioutil"
-
-	"gopkg.in/yaml.v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/jmccarty3/awsScaler/api/remediation"
+	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/record"
 	"k8s.io/kubernetes/pkg/client/restclient"
 	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
 )
 
 /* Reasons
@@ -42,60 +49,151 @@ const (
 )
 
 var (
-	argAPIServerURL       = flag.String("api-server", "", "Url endpoint of the k8s api server")
-	argConfigFile         = flag.String("config", "", "Path to the configuration file")
-	argRemediationMinutes = flag.Int64("remediation-timer", 5, "Time in (minutes) until remediation attempt")
-	argSyncNow            = flag.Bool("sync-now", false, "Sync as soon as initial sync is complete")
-	argSelfTest           = flag.Bool("self-test", false, "Startup Test")
+	argAPIServerURL                    = flag.String("api-server", "", "Url endpoint of the k8s api server")
+	argKubeconfig                      = flag.String("kubeconfig", "", "Path to a kubeconfig file. --api-server overrides its server when given")
+	argKubeContext                     = flag.String("context", "", "Kubeconfig context to use. Defaults to the current context")
+	argBearerToken                     = flag.String("token", "", "Bearer token for authenticating to the k8s api server")
+	argClientCert                      = flag.String("client-certificate", "", "Path to a client certificate for TLS authentication")
+	argClientKey                       = flag.String("client-key", "", "Path to a client key for TLS authentication")
+	argCertificateAuthority            = flag.String("certificate-authority", "", "Path to a CA certificate used to verify the k8s api server")
+	argInsecureSkipTLSVerify           = flag.Bool("insecure-skip-tls-verify", false, "Do not verify the k8s api server certificate")
+	argConfigFile                      = flag.String("config", "", "Path to the configuration file")
+	argConfigPollInterval              = flag.Duration("config-poll-interval", 30*time.Second, "How often to check the configuration file for changes. 0 only reloads on SIGHUP")
+	argConfigMap                       = flag.String("config-map", "", "ConfigMap (namespace/name) holding the configuration. Alternative to --config")
+	argConfigMapKey                    = flag.String("config-map-key", "config.yaml", "Key within the ConfigMap holding the configuration")
+	argScalingStrategies               = flag.Bool("scaling-strategies", false, "Also load strategies from ScalingStrategy resources. Requires the ThirdPartyResource to be registered")
+	argInstanceTypeRefresh             = flag.Duration("instance-type-refresh", 0, "How often to refresh the instance catalog with EC2 DescribeInstanceTypes. 0 only uses the builtin table and instanceCatalog file")
+	argLearnNodeCapacity               = flag.Bool("learn-node-capacity", true, "Use the allocatable resources of a registered node of a group as the capacity of new nodes from that group")
+	argDaemonSetOverhead               = flag.Bool("daemonset-overhead", true, "Subtract the requests of matching DaemonSet pods from the capacity of new nodes")
+	argScalingStrategyClusterNamespace = flag.String("scaling-strategy-cluster-namespace", "kube-system", "Namespace whose ScalingStrategies may match pods in any namespace")
+	argRemediationMinutes              = flag.Int64("remediation-timer", 5, "Time in (minutes) until remediation attempt")
+	argSyncNow                         = flag.Bool("sync-now", false, "Sync as soon as initial sync is complete")
+	argDryRun                          = flag.Bool("dry-run", false, "Plan remediations and log them without changing any capacity")
+	argListenAddress                   = flag.String("listen-address", ":8080", "Address to serve /metrics, /healthz, /readyz and /status on. Empty disables the HTTP server")
+	argAuditLog                        = flag.String("audit-log", "", "File to append a JSON record of every capacity change to. - writes to stdout. Empty disables")
+	argStatusConfigMap                 = flag.String("status-config-map", "", "ConfigMap (namespace/name) to write a summary of each remediation cycle to. Empty disables")
+	argStatusCycles                    = flag.Int("status-cycles", 10, "Number of recent remediation cycles reported by /status")
+	argLivenessCycles                  = flag.Int64("liveness-cycles", 3, "Number of remediation timer periods without a completed cycle before /healthz fails")
+	argMaxRemediations                 = flag.Int("max-remediations", MaxRemediations, "Number of remediations for a pod before giving up on it. 0 never gives up")
+	argRemediationResetMinutes         = flag.Int64("remediation-reset-minutes", 60, "Time in (minutes) before a given up pod is remediated again. 0 waits for the pod spec to change")
+	argInFlightTimeoutMinutes          = flag.Int64("in-flight-timeout", 15, "Time in (minutes) to wait for requested instances to register as nodes before considering the request failed")
+	argLeaderElect                     = flag.Bool("leader-elect", false, "Only remediate while holding a leader lease. Allows running multiple replicas")
+	argLeaderElectNamespace            = flag.String("leader-elect-namespace", "kube-system", "Namespace of the Endpoints object holding the leader lease")
+	argLeaderElectName                 = flag.String("leader-elect-name", "aws-scaler", "Name of the Endpoints object holding the leader lease")
+	argLeaderElectLeaseDuration        = flag.Duration("leader-elect-lease-duration", 15*time.Second, "Time non leaders wait before attempting to take over an unrenewed lease")
+	argLeaderElectRenewDeadline        = flag.Duration("leader-elect-renew-deadline", 10*time.Second, "Time the leader retries renewing its lease before giving up leadership")
+	argLeaderElectRetryPeriod          = flag.Duration("leader-elect-retry-period", 2*time.Second, "Time to wait between leader election attempts")
 )
 
-func getAPIClient() (*kclient.Client, error) {
-	var restConfig *restclient.Config
-	var err error
+func getRestConfig() (*restclient.Config, error) {
+	if *argKubeconfig != "" || *argKubeContext != "" {
+		rules := clientcmd.NewDefaultClientConfigLoadingRules()
+		rules.ExplicitPath = *argKubeconfig
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: *argKubeContext}
+		overrides.ClusterInfo.Server = *argAPIServerURL
+		glog.Infof("Using kubeconfig %q with context %q", *argKubeconfig, *argKubeContext)
+		return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	}
 
 	if *argAPIServerURL == "" {
 		glog.Info("No API Endpoint. Using incluster config")
-		restConfig, err = restclient.InClusterConfig()
-	} else {
-		restConfig = &restclient.Config{
-			Host: *argAPIServerURL,
-		}
+		return restclient.InClusterConfig()
 	}
 
+	return &restclient.Config{
+		Host: *argAPIServerURL,
+	}, nil
+}
+
+func getAPIClient() (*kclient.Client, error) {
+	restConfig, err := getRestConfig()
 	if err != nil {
 		glog.Errorf("Could not create rest config: %v", err)
 		return nil, err
 	}
 
-	return kclient.New(restConfig)
+	//Explicit credentials override anything loaded from a kubeconfig or the cluster
+	if *argBearerToken != "" {
+		restConfig.BearerToken = *argBearerToken
+	}
+	if *argClientCert != "" {
+		restConfig.TLSClientConfig.CertFile = *argClientCert
+	}
+	if *argClientKey != "" {
+		restConfig.TLSClientConfig.KeyFile = *argClientKey
+	}
+	if *argCertificateAuthority != "" {
+		restConfig.TLSClientConfig.CAFile = *argCertificateAuthority
+	}
+	if *argInsecureSkipTLSVerify {
+		restConfig.Insecure = true
+	}
+
+	client, err := kclient.New(restConfig)
+	if err != nil {
+		glog.Errorf("Could not create kubernetes client for %s: %v", restConfig.Host, err)
+		return nil, err
+	}
+
+	return client, nil
 }
 
-func main() {
-	flag.Parse()
+// handleSignals closes the returned channel on SIGTERM or SIGINT. A second signal exits immediately
+func handleSignals() <-chan struct{} {
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		sig := <-signals
+		glog.Infof("Received %v. Shutting down once the current remediation step completes", sig)
+		close(stop)
+		sig = <-signals
+		glog.Warningf("Received %v again. Exiting immediately", sig)
+		glog.Flush()
+		os.Exit(1)
+	}()
 
-	if *argSelfTest {
-		fmt.Println("Started!")
-		return
+	return stop
+}
+
+// openAuditLog opens the audit log for appending. - is stdout
+func openAuditLog(path string) (*os.File, error) {
+	if path == "-" {
+		return os.Stdout, nil
 	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+}
 
-	if *argConfigFile == "" {
-		panic("No config file given")
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "plan":
+			os.Exit(runPlan(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		}
 	}
 
-	var config Config
+	flag.Parse()
 
-	configData, err := ioutil.ReadFile(*argConfigFile)
-	if err != nil {
-		panic(fmt.Sprintf("Error loading config file: %v", err))
+	if (*argConfigFile == "") == (*argConfigMap == "") {
+		panic("Exactly one of --config or --config-map must be given")
 	}
 
-	err = yaml.Unmarshal(configData, &config)
+	stop := handleSignals()
 
-	if err != nil {
-		panic(fmt.Sprintf("Error parsing config file: %v", err))
+	//The first cycle runs after the informers sync and a full timer period so allow one extra period
+	health := newHealthChecker(time.Duration(*argLivenessCycles+1) * time.Duration(*argRemediationMinutes) * time.Minute)
+	go health.checkAWS(raws.CheckAccess, 30*time.Second, stop)
+	if *argInstanceTypeRefresh > 0 {
+		go raws.DefaultCatalog.RefreshFromEC2(*argInstanceTypeRefresh, stop)
 	}
 
-	kubeApiClient, _ := getAPIClient()
+	kubeApiClient, err := getAPIClient()
+	if err != nil {
+		panic(fmt.Sprintf("Unable to create k8s API client: %v", err))
+	}
 	version, err := kubeApiClient.ServerVersion() //Verify we can talk to the server
 	if err != nil {
 		panic(fmt.Sprintf("Unable to fetch server version from k8s API: %v", err))
@@ -103,10 +201,80 @@ func main() {
 		fmt.Println("Server Version:", version)
 	}
 
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(glog.Infof)
+	broadcaster.StartRecordingToSink(kubeApiClient.Events(""))
+	recorder := broadcaster.NewRecorder(api.EventSource{Component: "awsScaler"})
+
 	provider := newKubeDataProvider(kubeApiClient)
-	provider.Run(config.Strategies)
+	provider.health = health
+	provider.recorder = recorder
+	provider.instanceGroups = raws.NewInstanceGroups()
+	provider.learnCapacity = *argLearnNodeCapacity
+	if *argAuditLog != "" {
+		auditFile, err := openAuditLog(*argAuditLog)
+		if err != nil {
+			panic(fmt.Sprintf("Unable to open audit log: %v", err))
+		}
+		defer auditFile.Close()
+		provider.auditLog = remediation.NewAuditLog(auditFile)
+	}
+	if *argStatusConfigMap != "" {
+		namespace, name, err := parseConfigMapRef(*argStatusConfigMap)
+		if err != nil {
+			panic(err.Error())
+		}
+		provider.statusConfigMap = newStatusConfigMap(kubeApiClient, namespace, name)
+	}
 
-	kubeApiClient.Pods(api.NamespaceAll)
+	if *argListenAddress != "" {
+		http.Handle("/metrics", prometheus.Handler())
+		http.HandleFunc("/healthz", health.ServeLive)
+		http.HandleFunc("/readyz", health.ServeReady)
+		http.HandleFunc("/status", provider.ServeStatus)
+		go func() {
+			glog.Fatalf("HTTP server on %s failed: %v", *argListenAddress, http.ListenAndServe(*argListenAddress, nil))
+		}()
+	}
+
+	applyConfig := func(c *Config) {
+		c.applyInstanceCatalog()
+		provider.SetStrategies(c.Strategies)
+	}
+
+	//The initial config is applied before the watchers start so a reload is never overwritten by it
+	if *argConfigMap != "" {
+		namespace, name, err := parseConfigMapRef(*argConfigMap)
+		if err != nil {
+			panic(err.Error())
+		}
+		watcher := newConfigMapWatcher(kubeApiClient, namespace, name, *argConfigMapKey, applyConfig)
+		config, err := watcher.Load()
+		if err != nil {
+			panic(err.Error())
+		}
+		applyConfig(config)
+		go watcher.Run(stop)
+	} else {
+		//The watcher reads the file before it is loaded. A change in between is applied again rather than missed
+		watcher := newConfigFileWatcher(*argConfigFile, applyConfig)
+		config, err := loadConfig(*argConfigFile)
+		if err != nil {
+			panic(err.Error())
+		}
+		applyConfig(config)
+		go watcher.Run(*argConfigPollInterval, stop)
+	}
+
+	if *argLeaderElect {
+		provider.leader, err = newLeaderElection(kubeApiClient, recorder, *argLeaderElectNamespace, *argLeaderElectName)
+		if err != nil {
+			panic(fmt.Sprintf("Unable to create leader election: %v", err))
+		}
+		go provider.leader.Run(stop)
+	}
+	provider.Run(stop)
 
-	select {}
+	glog.Info("Shutdown complete")
+	glog.Flush()
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//Remediation error types reported by remediationErrors
+const (
+	ErrorUnresolved      = "unresolved"
+	ErrorCancelled       = "cancelled"
+	ErrorNoStrategy      = "no_strategy"
+	ErrorInFlightExpired = "in_flight_expired"
+)
+
+//NoStrategyLabel labels pods that no strategy matched
+const NoStrategyLabel = "none"
+
+var (
+	failingPodsByReason = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "aws_scaler",
+		Name:      "failing_pods_by_reason",
+		Help:      "Pods failing to schedule by scheduler reason, including pods given up on",
+	}, []string{"reason"})
+	failingPodsByStrategy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "aws_scaler",
+		Name:      "failing_pods_by_strategy",
+		Help:      "Remediable pods matched by each strategy during the last cycle",
+	}, []string{"strategy"})
+	remediationCycles = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "aws_scaler",
+		Name:      "remediation_cycles_total",
+		Help:      "Remediation cycles run while leading",
+	})
+	scaleUpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "aws_scaler",
+		Name:      "scale_up_requests_total",
+		Help:      "Capacity increases requested from each autoscaling group",
+	}, []string{"group"})
+	instancesRequested = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "aws_scaler",
+		Name:      "instances_requested_total",
+		Help:      "Instances requested from each autoscaling group",
+	}, []string{"group"})
+	remediationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "aws_scaler",
+		Name:      "remediation_errors_total",
+		Help:      "Remediation failures by type",
+	}, []string{"type"})
+	unresolvedCPU = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "aws_scaler",
+		Name:      "unresolved_cpu_millicores",
+		Help:      "CPU a strategy was unable to remediate during the last cycle",
+	}, []string{"strategy"})
+	unresolvedMemMB = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "aws_scaler",
+		Name:      "unresolved_memory_mb",
+		Help:      "Memory a strategy was unable to remediate during the last cycle",
+	}, []string{"strategy"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		failingPodsByReason,
+		failingPodsByStrategy,
+		remediationCycles,
+		scaleUpRequests,
+		instancesRequested,
+		remediationErrors,
+		unresolvedCPU,
+		unresolvedMemMB,
+	)
+}
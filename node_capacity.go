@@ -0,0 +1,106 @@
+package main
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	rapi "github.com/jmccarty3/awsScaler/api"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+)
+
+//instanceGroupLookup maps instances to the autoscaling group they belong to
+type instanceGroupLookup interface {
+	Lookup(instanceIDs []string) (map[string]string, error)
+}
+
+//observedNode is the newest registered node of a group
+type observedNode struct {
+	instanceType string
+	allocatable  rapi.Resources
+	created      time.Time
+}
+
+//resourcesFromList converts resources reported by a node such as its allocatable resources
+func resourcesFromList(list api.ResourceList) *rapi.Resources {
+	r := &rapi.Resources{}
+	for name, q := range list {
+		switch name {
+		case api.ResourceCPU:
+			r.CPU = q.MilliValue()
+		case api.ResourceMemory:
+			r.MemMB = q.Value() / (1024 * 1024)
+		case api.ResourcePods:
+			r.Pods = q.Value()
+		case rapi.ResourceEphemeralStorage:
+			r.EphemeralStorageMB = q.Value() / (1024 * 1024)
+		default:
+			if extendedName, extended := extendedResourceName(name); extended {
+				r.Set(extendedName, r.Get(extendedName)+q.Value())
+			}
+		}
+	}
+	return r
+}
+
+//nodeGroups returns the registered nodes by instance id and the autoscaling group of every instance belonging to one
+func (k *kubeDataProvider) nodeGroups() (map[string]*api.Node, map[string]string, error) {
+	nodes := make(map[string]*api.Node)
+	var instanceIDs []string
+	for _, obj := range k.nodes.Store.List() {
+		node := obj.(*api.Node)
+		if id := instanceIDForNode(node); id != "" {
+			nodes[id] = node
+			instanceIDs = append(instanceIDs, id)
+		}
+	}
+
+	groups, err := k.instanceGroups.Lookup(instanceIDs)
+	return nodes, groups, err
+}
+
+//learnNodeCapacity records the allocatable resources of the newest registered node of every autoscaling group
+func (k *kubeDataProvider) learnNodeCapacity() {
+	if !k.learnCapacity || k.instanceGroups == nil {
+		return
+	}
+
+	nodes, groups, err := k.nodeGroups()
+	if err != nil {
+		glog.Warningf("Unable to map nodes to autoscaling groups. Keeping capacity learned before: %v", err)
+		return
+	}
+
+	observed := make(map[string]observedNode)
+	for id, group := range groups {
+		node := nodes[id]
+		if len(node.Status.Allocatable) == 0 {
+			continue
+		}
+		if newest, exists := observed[group]; exists && !newest.created.Before(node.CreationTimestamp.Time) {
+			continue
+		}
+		observed[group] = observedNode{
+			instanceType: node.Labels[unversioned.LabelInstanceType],
+			allocatable:  *resourcesFromList(node.Status.Allocatable),
+			created:      node.CreationTimestamp.Time,
+		}
+	}
+
+	k.observedLock.Lock()
+	defer k.observedLock.Unlock()
+	k.observedCapacity = observed
+}
+
+//ObservedCapacity returns the allocatable resources of the newest registered node of the group.
+//Nodes labelled with a different instance type predate a change of launch configuration and are not used
+func (k *kubeDataProvider) ObservedCapacity(group, instanceType string) (*rapi.Resources, bool) {
+	k.observedLock.Lock()
+	defer k.observedLock.Unlock()
+
+	node, exists := k.observedCapacity[group]
+	if !exists || (node.instanceType != "" && node.instanceType != instanceType) {
+		return nil, false
+	}
+	return node.allocatable.Copy(), true
+}
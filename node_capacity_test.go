@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	rapi "github.com/jmccarty3/awsScaler/api"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/cache"
+)
+
+type fakeInstanceGroups struct {
+	groups map[string]string
+	err    error
+}
+
+func (f *fakeInstanceGroups) Lookup(instanceIDs []string) (map[string]string, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	groups := make(map[string]string)
+	for _, id := range instanceIDs {
+		if group, exists := f.groups[id]; exists {
+			groups[id] = group
+		}
+	}
+	return groups, nil
+}
+
+func makeCapacityNode(instanceID, instanceType, cpu string, created time.Time) *api.Node {
+	return &api.Node{
+		ObjectMeta: api.ObjectMeta{
+			Name:              instanceID,
+			Labels:            map[string]string{unversioned.LabelInstanceType: instanceType},
+			CreationTimestamp: unversioned.NewTime(created),
+		},
+		Spec: api.NodeSpec{ProviderID: "aws:///us-east-1a/" + instanceID},
+		Status: api.NodeStatus{
+			Allocatable: api.ResourceList{
+				api.ResourceCPU:    resource.MustParse(cpu),
+				api.ResourceMemory: resource.MustParse("14Gi"),
+				api.ResourcePods:   resource.MustParse("110"),
+				legacyGPUResource:  resource.MustParse("1"),
+			},
+		},
+	}
+}
+
+func TestLearnNodeCapacity(t *testing.T) {
+	now := time.Now()
+	lookup := &fakeInstanceGroups{groups: map[string]string{"i-old": "workers", "i-new": "workers", "i-gpu": "gpus"}}
+	k := &kubeDataProvider{instanceGroups: lookup, learnCapacity: true}
+	k.nodes.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	k.nodes.Store.Add(makeCapacityNode("i-old", "m4.xlarge", "3500m", now.Add(-time.Hour)))
+	k.nodes.Store.Add(makeCapacityNode("i-new", "m4.xlarge", "3800m", now))
+	k.nodes.Store.Add(makeCapacityNode("i-gpu", "p2.xlarge", "3900m", now))
+	k.nodes.Store.Add(makeCapacityNode("i-other", "m4.xlarge", "1", now))
+
+	k.learnNodeCapacity()
+
+	workers, exists := k.ObservedCapacity("workers", "m4.xlarge")
+	expected := (&rapi.Resources{CPU: 3800, MemMB: 14336, Pods: 110}).Set(rapi.ResourceGPU, 1)
+	if !exists || !workers.Equal(expected) {
+		t.Errorf("Expected the newest node's allocatable %v. Got %v", expected, workers)
+	}
+	if _, exists = k.ObservedCapacity("gpus", "p3.2xlarge"); exists {
+		t.Error("Expected no capacity once the launch configuration uses another instance type")
+	}
+	if _, exists = k.ObservedCapacity("unknown", "m4.xlarge"); exists {
+		t.Error("Expected no capacity for a group without nodes")
+	}
+
+	//A failed lookup keeps the capacity learned before
+	lookup.err = errors.New("throttled")
+	k.learnNodeCapacity()
+	if _, exists = k.ObservedCapacity("workers", "m4.xlarge"); !exists {
+		t.Error("Capacity lost after a failed lookup")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/jmccarty3/awsScaler/api/remediation"
+	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
+	"github.com/jmccarty3/awsScaler/api/remediation/remediators/webhook"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/client/cache"
+	"k8s.io/kubernetes/pkg/runtime"
+	utilyaml "k8s.io/kubernetes/pkg/util/yaml"
+)
+
+//loadPods reads pods from a YAML or JSON file containing Pod or PodList documents
+func loadPods(path string) ([]*api.Pod, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []*api.Pod
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("Error reading %s: %v", path, err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+
+		obj, err := runtime.Decode(api.Codecs.UniversalDecoder(), raw)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding %s: %v", path, err)
+		}
+
+		switch o := obj.(type) {
+		case *api.Pod:
+			pods = append(pods, o)
+		case *api.PodList:
+			for i := range o.Items {
+				pods = append(pods, &o.Items[i])
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported object %T in %s. Expected Pod or PodList", obj, path)
+		}
+	}
+
+	return pods, nil
+}
+
+//loadGroups reads autoscaling group descriptions from a JSON file
+func loadGroups(path string) ([]raws.StaticGroup, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []raws.StaticGroup
+	if err = json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %v", path, err)
+	}
+	return groups, nil
+}
+
+//runPlan evaluates a configuration against pod manifests and local group descriptions without a cluster or AWS
+func runPlan(args []string) int {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to the configuration file")
+	podsPath := flags.String("pods", "", "Path to a YAML or JSON file of pending pods")
+	groupsPath := flags.String("groups", "", "Path to a JSON file describing autoscaling groups")
+	flags.Parse(args)
+
+	if *configPath == "" || *podsPath == "" || *groupsPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: awsScaler plan --config config.yaml --pods pods.yaml --groups groups.json")
+		return 1
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	pods, err := loadPods(*podsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	groups, err := loadGroups(*groupsPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	client, err := raws.NewStaticAutoscalingClient(groups)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	prepareOffline(config, client, os.Stderr)
+	fmt.Fprint(os.Stdout, plan(config, pods))
+	return 0
+}
+
+//prepareOffline points the strategies' remediators at the local groups and the instance catalog of the config.
+//Anything that would reach outside the process, such as selfTags needing the instance metadata service or webhooks,
+//is removed with a warning
+func prepareOffline(config *Config, client raws.AutoscalingClient, warnings io.Writer) {
+	config.applyInstanceCatalog()
+	for i := range config.Strategies {
+		var remediators []remediation.Remediator
+		for _, r := range config.Strategies[i].Remediators {
+			switch remediator := r.(type) {
+			case *raws.ASGRemediator:
+				if len(remediator.SelfTags) != 0 {
+					fmt.Fprintf(warnings, "Strategy %d: selfTags require the instance metadata service and are ignored\n", i)
+					remediator.SelfTags = nil
+				}
+				remediator.SetClient(client)
+			case *webhook.Remediator:
+				fmt.Fprintf(warnings, "Strategy %d: webhook %s is not called while planning and is ignored\n", i, remediator.URL)
+				continue
+			}
+			remediators = append(remediators, r)
+		}
+		config.Strategies[i].Remediators = remediators
+	}
+}
+
+//plan runs pods through the configured strategies and describes the result
+func plan(config *Config, pods []*api.Pod) string {
+	var out bytes.Buffer
+	remaining := pods
+
+	for i := range config.Strategies {
+		var matched []*api.Pod
+		matched, remaining = config.Strategies[i].FilterPods(remaining)
+		if len(matched) == 0 {
+			fmt.Fprintf(&out, "Strategy %d: no pods\n", i)
+			continue
+		}
+
+		keys := make([]string, len(matched))
+		demands := make([]remediation.PodDemand, len(matched))
+		for j, pod := range matched {
+			keys[j], _ = cache.MetaNamespaceKeyFunc(pod)
+			demands[j] = remediation.PodDemand{Pod: keys[j], Resources: *getNeededResources([]*api.Pod{pod})}
+		}
+
+		resources := getNeededResources(matched)
+		fmt.Fprintf(&out, "Strategy %d: %d pods need CPU: %d MemMB: %d\n", i, len(matched), resources.CPU, resources.MemMB)
+		for _, key := range keys {
+			fmt.Fprintf(&out, "  pod %s\n", key)
+		}
+
+		req := &remediation.Request{Pods: keys, Demands: demands}
+		unresolved, err := config.Strategies[i].DoRemediation(resources, req)
+		for _, scaleUp := range req.ScaleUps {
+			fmt.Fprintf(&out, "  group %s: %d -> %d (+%d instances)\n", scaleUp.Group, scaleUp.FromSize, scaleUp.ToSize, scaleUp.Instances)
+		}
+		if err != nil {
+			fmt.Fprintf(&out, "  unresolved CPU: %d MemMB: %d (%v)\n", unresolved.CPU, unresolved.MemMB, err)
+		}
+	}
+
+	for _, pod := range remaining {
+		key, _ := cache.MetaNamespaceKeyFunc(pod)
+		fmt.Fprintf(&out, "No strategy: pod %s\n", key)
+	}
+
+	return out.String()
+}
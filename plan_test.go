@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+)
+
+func makePod(namespace, name, cpu string) *api.Pod {
+	return &api.Pod{
+		ObjectMeta: api.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: api.PodSpec{
+			Containers: []api.Container{
+				{
+					Resources: api.ResourceRequirements{
+						Requests: api.ResourceList{
+							api.ResourceCPU:    resource.MustParse(cpu),
+							api.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	var planConfig = `
+strategies:
+- namespaces:
+  - alpha
+  remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(planConfig), &config); err != nil {
+		t.Fatalf("Unexpected unmarshaling error. %v", err)
+	}
+
+	client, err := raws.NewStaticAutoscalingClient([]raws.StaticGroup{
+		{
+			Name:            "foo",
+			InstanceType:    "m4.xlarge",
+			DesiredCapacity: 1,
+			MaxSize:         10,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating client. %v", err)
+	}
+	config.Strategies[0].Remediators[0].(*raws.ASGRemediator).SetClient(client)
+
+	pods := []*api.Pod{
+		makePod("alpha", "one", "3"),
+		makePod("alpha", "two", "3"),
+		makePod("alpha", "three", "3"),
+		makePod("beta", "four", "1"),
+	}
+
+	output := plan(&config, pods)
+	expected := []string{
+		"Strategy 0: 3 pods need CPU: 9000",
+		"pod alpha/one",
+		"group foo: 1 -> 4 (+3 instances)",
+		"No strategy: pod beta/four",
+	}
+	for _, e := range expected {
+		if !strings.Contains(output, e) {
+			t.Errorf("Expected plan to contain %q. Actual:\n%s", e, output)
+		}
+	}
+}
+
+func TestPlanSkipsWebhooks(t *testing.T) {
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	var planConfig = `
+strategies:
+- remediators:
+  - webhook:
+      url: ` + server.URL + `
+      headers:
+        Authorization: Bearer secret
+  - autoScalingGroup:
+      names:
+      - foo
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(planConfig), &config); err != nil {
+		t.Fatalf("Unexpected unmarshaling error. %v", err)
+	}
+
+	client, err := raws.NewStaticAutoscalingClient([]raws.StaticGroup{
+		{Name: "foo", InstanceType: "m4.xlarge", DesiredCapacity: 1, MaxSize: 10},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating client. %v", err)
+	}
+
+	var warnings bytes.Buffer
+	prepareOffline(&config, client, &warnings)
+	output := plan(&config, []*api.Pod{makePod("alpha", "one", "3")})
+
+	if received != 0 {
+		t.Errorf("Expected planning to send nothing to the webhook. Received %d requests", received)
+	}
+	if !strings.Contains(warnings.String(), "webhook "+server.URL+" is not called") {
+		t.Errorf("Expected a warning about the skipped webhook. Got %q", warnings.String())
+	}
+	if !strings.Contains(output, "group foo: 1 -> 2 (+1 instances)") {
+		t.Errorf("Expected the remaining remediators to be planned. Actual:\n%s", output)
+	}
+}
+
+func TestPlanUsesInstanceCatalog(t *testing.T) {
+	var planConfig = `
+strategies:
+- remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(planConfig), &config); err != nil {
+		t.Fatalf("Unexpected unmarshaling error. %v", err)
+	}
+	config.InstanceTypes = []raws.InstanceType{{Name: "custom.large", CPU: 8000, MemoryMB: 32768}}
+	defer raws.DefaultCatalog.SetOverrides(nil)
+
+	client, err := raws.NewStaticAutoscalingClient([]raws.StaticGroup{
+		{Name: "foo", InstanceType: "custom.large", DesiredCapacity: 1, MaxSize: 10},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating client. %v", err)
+	}
+
+	var warnings bytes.Buffer
+	prepareOffline(&config, client, &warnings)
+	output := plan(&config, []*api.Pod{
+		makePod("alpha", "one", "3"),
+		makePod("alpha", "two", "3"),
+		makePod("alpha", "three", "3"),
+	})
+
+	if !strings.Contains(output, "group foo: 1 -> 3 (+2 instances)") {
+		t.Errorf("Expected the catalog instance type to be used. Actual:\n%s", output)
+	}
+}
+
+func TestPlanPacksPods(t *testing.T) {
+	var planConfig = `
+strategies:
+- remediators:
+  - autoScalingGroup:
+      names:
+      - foo
+`
+	var config Config
+	if err := yaml.Unmarshal([]byte(planConfig), &config); err != nil {
+		t.Fatalf("Unexpected unmarshaling error. %v", err)
+	}
+
+	client, err := raws.NewStaticAutoscalingClient([]raws.StaticGroup{
+		{Name: "foo", InstanceType: "m4.xlarge", DesiredCapacity: 1, MaxSize: 10},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating client. %v", err)
+	}
+
+	var warnings bytes.Buffer
+	prepareOffline(&config, client, &warnings)
+
+	//Five 3 CPU pods fit onto four 4 CPU instances by their sum but need one instance each
+	var pods []*api.Pod
+	for _, name := range []string{"one", "two", "three", "four", "five"} {
+		pods = append(pods, makePod("alpha", name, "3"))
+	}
+	output := plan(&config, pods)
+
+	if !strings.Contains(output, "group foo: 1 -> 6 (+5 instances)") {
+		t.Errorf("Expected the pods to be packed one per instance. Actual:\n%s", output)
+	}
+}
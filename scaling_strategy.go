@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	rapi "github.com/jmccarty3/awsScaler/api"
+	"github.com/jmccarty3/awsScaler/api/remediation"
+	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
+	"github.com/jmccarty3/awsScaler/api/strategy"
+	"gopkg.in/yaml.v2"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/client/cache"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+//ThirdPartyResource coordinates of the ScalingStrategy resource
+const (
+	ScalingStrategyGroup    = "awsscaler.jmccarty3.github.io"
+	ScalingStrategyVersion  = "v1"
+	ScalingStrategyKind     = "ScalingStrategy"
+	scalingStrategyResource = "scalingstrategies"
+)
+
+//ScalingStrategy is a remediation strategy stored in the cluster
+type ScalingStrategy struct {
+	unversioned.TypeMeta `json:",inline"`
+	api.ObjectMeta       `json:"metadata,omitempty"`
+
+	//Spec uses the same keys as a strategy within the configuration file
+	Spec   map[string]interface{} `json:"spec"`
+	Status ScalingStrategyStatus  `json:"status,omitempty"`
+}
+
+//ScalingStrategyStatus reports the outcome of the last remediation cycle for a ScalingStrategy
+type ScalingStrategyStatus struct {
+	LastMatchCount      int               `json:"lastMatchCount"`
+	LastRemediationTime *unversioned.Time `json:"lastRemediationTime,omitempty"`
+	LastError           string            `json:"lastError,omitempty"`
+}
+
+//ScalingStrategyList is a list of ScalingStrategies
+type ScalingStrategyList struct {
+	unversioned.TypeMeta `json:",inline"`
+	unversioned.ListMeta `json:"metadata,omitempty"`
+
+	Items []ScalingStrategy `json:"items"`
+}
+
+//customStrategy is the strategy built from a ScalingStrategy along with its reported status
+type customStrategy struct {
+	spec     map[string]interface{}
+	strategy *strategy.RemediationStrategy //nil if the spec is invalid
+	status   ScalingStrategyStatus
+}
+
+//strategyResult is what happened to a strategy during a remediation cycle
+type strategyResult struct {
+	matched   int
+	attempted bool
+	scaledUp  bool
+	err       error
+}
+
+func scalingStrategyPath(namespace, name string) string {
+	path := "/apis/" + ScalingStrategyGroup + "/" + ScalingStrategyVersion
+	if namespace != api.NamespaceAll {
+		path += "/namespaces/" + namespace
+	}
+	path += "/" + scalingStrategyResource
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+//scalingStrategyDecoder decodes a watch stream of ScalingStrategies
+type scalingStrategyDecoder struct {
+	stream  io.ReadCloser
+	decoder *json.Decoder
+}
+
+//Decode returns the next watch event from the stream
+func (d *scalingStrategyDecoder) Decode() (watch.EventType, runtime.Object, error) {
+	var event struct {
+		Type   watch.EventType `json:"type"`
+		Object ScalingStrategy `json:"object"`
+	}
+	if err := d.decoder.Decode(&event); err != nil {
+		return "", nil, err
+	}
+	return event.Type, &event.Object, nil
+}
+
+//Close closes the underlying stream
+func (d *scalingStrategyDecoder) Close() {
+	d.stream.Close()
+}
+
+//createScalingStrategyListWatcher lists and watches ScalingStrategies in all namespaces.
+//Third party resources are not known to the client codecs so they are decoded as plain json
+func createScalingStrategyListWatcher(client *kclient.Client) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			data, err := client.RESTClient.Get().AbsPath(scalingStrategyPath(api.NamespaceAll, "")).DoRaw()
+			if err != nil {
+				return nil, err
+			}
+			list := &ScalingStrategyList{}
+			if err := json.Unmarshal(data, list); err != nil {
+				return nil, fmt.Errorf("Failed to decode ScalingStrategy list: %v", err)
+			}
+			return list, nil
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			stream, err := client.RESTClient.Get().
+				AbsPath(scalingStrategyPath(api.NamespaceAll, "")).
+				Param("watch", "true").
+				Param("resourceVersion", options.ResourceVersion).
+				Stream()
+			if err != nil {
+				return nil, err
+			}
+			return watch.NewStreamWatcher(&scalingStrategyDecoder{stream: stream, decoder: json.NewDecoder(stream)}), nil
+		},
+	}
+}
+
+//strategyFromScalingStrategy builds a RemediationStrategy from a ScalingStrategy.
+//Strategies outside of clusterNamespace may only match pods in their own namespace and only scale groups tagged for it
+func strategyFromScalingStrategy(s *ScalingStrategy, clusterNamespace string) (*strategy.RemediationStrategy, error) {
+	if len(s.Spec) == 0 {
+		return nil, fmt.Errorf("spec: Missing")
+	}
+
+	//JSON is valid yaml so the spec is parsed exactly like the configuration file
+	data, err := json.Marshal(s.Spec)
+	if err != nil {
+		return nil, err
+	}
+	rs := &strategy.RemediationStrategy{}
+	if err := yaml.Unmarshal(data, rs); err != nil {
+		return nil, err
+	}
+	rs.Name, _ = cache.MetaNamespaceKeyFunc(s)
+
+	if s.Namespace == clusterNamespace {
+		return rs, nil
+	}
+	if rs.Namespaces != nil {
+		for _, ns := range rs.Namespaces.Namespaces {
+			if ns != s.Namespace {
+				return nil, fmt.Errorf("namespaces: Strategies outside of %s may only match pods in their own namespace. Got %s", clusterNamespace, ns)
+			}
+		}
+	}
+	rs.Namespaces = &rapi.NamespaceCondition{Namespaces: []string{s.Namespace}}
+
+	for i, r := range rs.Remediators {
+		restricter, ok := r.(remediation.NamespaceRestricter)
+		if !ok {
+			return nil, fmt.Errorf("remediators[%d]: Strategies outside of %s may only use remediators restricted to groups tagged %s", i, clusterNamespace, raws.ScalerNamespacesTagKey)
+		}
+		restricter.RestrictToNamespace(s.Namespace)
+	}
+	return rs, nil
+}
+
+//syncScalingStrategies rebuilds strategies whose ScalingStrategy spec changed.
+//Status only updates are ignored so strategies keep their cooldown state
+func (k *kubeDataProvider) syncScalingStrategies() {
+	k.strategyLock.Lock()
+	defer k.strategyLock.Unlock()
+
+	changed := false
+	custom := map[string]*customStrategy{}
+	for _, obj := range k.scalingStrategies.List() {
+		s := obj.(*ScalingStrategy)
+		key, _ := cache.MetaNamespaceKeyFunc(s)
+		if existing, ok := k.customStrategies[key]; ok && reflect.DeepEqual(existing.spec, s.Spec) {
+			custom[key] = existing
+			continue
+		}
+
+		c := &customStrategy{spec: s.Spec, status: s.Status}
+		var err error
+		if c.strategy, err = strategyFromScalingStrategy(s, *argScalingStrategyClusterNamespace); err != nil {
+			glog.Errorf("ScalingStrategy %s is invalid: %v", key, err)
+			c.status.LastError = err.Error()
+		} else {
+			glog.Infof("Loaded ScalingStrategy %s", key)
+			c.status.LastError = ""
+		}
+		custom[key] = c
+		changed = true
+	}
+
+	if changed || len(custom) != len(k.customStrategies) {
+		k.customStrategies = custom
+		k.strategiesChanged = true
+	}
+}
+
+//orderedCustomStrategies returns the valid custom strategies ordered by namespace and name
+func orderedCustomStrategies(custom map[string]*customStrategy) []*strategy.RemediationStrategy {
+	keys := make([]string, 0, len(custom))
+	for key := range custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var strategies []*strategy.RemediationStrategy
+	for _, key := range keys {
+		if custom[key].strategy != nil {
+			strategies = append(strategies, custom[key].strategy)
+		}
+	}
+	return strategies
+}
+
+//reportScalingStrategies records the results of a remediation cycle and writes changed statuses back to the cluster
+func (k *kubeDataProvider) reportScalingStrategies(results map[string]*strategyResult, now time.Time) {
+	if k.scalingStrategies == nil {
+		return
+	}
+
+	updates := map[string]ScalingStrategyStatus{}
+	k.strategyLock.Lock()
+	for key, c := range k.customStrategies {
+		c.status.LastMatchCount = 0
+		if result, ok := results[key]; ok && c.strategy != nil {
+			c.status.LastMatchCount = result.matched
+			if result.scaledUp {
+				t := unversioned.NewTime(now)
+				c.status.LastRemediationTime = &t
+			}
+			if result.attempted {
+				c.status.LastError = ""
+				if result.err != nil {
+					c.status.LastError = result.err.Error()
+				}
+			}
+		}
+		updates[key] = c.status
+	}
+	k.strategyLock.Unlock()
+
+	for key, status := range updates {
+		obj, exists, _ := k.scalingStrategies.GetByKey(key)
+		if !exists {
+			continue
+		}
+		s := *obj.(*ScalingStrategy)
+		if reflect.DeepEqual(s.Status, status) {
+			continue
+		}
+		s.Status = status
+		if err := updateScalingStrategy(k.client, &s); err != nil {
+			glog.Warningf("Unable to update status of ScalingStrategy %s: %v", key, err)
+		}
+	}
+}
+
+//updateScalingStrategy writes a ScalingStrategy back to the cluster. Third party resources have no status subresource so the whole object is replaced.
+//A conflicting change to the object fails the update and is retried next cycle
+func updateScalingStrategy(client *kclient.Client, s *ScalingStrategy) error {
+	s.Kind = ScalingStrategyKind
+	s.APIVersion = ScalingStrategyGroup + "/" + ScalingStrategyVersion
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = client.RESTClient.Put().AbsPath(scalingStrategyPath(s.Namespace, s.Name)).Body(data).DoRaw()
+	return err
+}
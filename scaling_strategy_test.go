@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	raws "github.com/jmccarty3/awsScaler/api/remediation/remediators/aws"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+func makeScalingStrategy(namespace, name, spec string) *ScalingStrategy {
+	s := &ScalingStrategy{
+		ObjectMeta: api.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+	}
+	if spec != "" {
+		json.Unmarshal([]byte(spec), &s.Spec)
+	}
+	return s
+}
+
+func TestStrategyFromScalingStrategy(t *testing.T) {
+	tests := []struct {
+		namespace  string
+		spec       string
+		contains   string
+		namespaces []string
+	}{
+		{
+			namespace:  "alpha",
+			spec:       `{"remediators": [{"autoScalingGroup": {"names": ["foo"]}}]}`,
+			namespaces: []string{"alpha"},
+		},
+		{
+			namespace:  "alpha",
+			spec:       `{"namespaces": ["alpha"], "cooldown": "5m", "remediators": [{"autoScalingGroup": {"names": ["foo"]}}]}`,
+			namespaces: []string{"alpha"},
+		},
+		{
+			namespace: "alpha",
+			spec:      `{"namespaces": ["alpha", "beta"], "remediators": [{"autoScalingGroup": {"names": ["foo"]}}]}`,
+			contains:  "own namespace",
+		},
+		{
+			namespace:  "kube-system",
+			spec:       `{"namespaces": ["alpha", "beta"], "remediators": [{"autoScalingGroup": {"names": ["foo"]}}]}`,
+			namespaces: []string{"alpha", "beta"},
+		},
+		{
+			namespace: "kube-system",
+			spec:      `{"remediators": [{"autoScalingGroup": {"names": ["foo"]}}]}`,
+		},
+		{
+			namespace: "alpha",
+			contains:  "spec: Missing",
+		},
+		{
+			namespace: "alpha",
+			spec:      `{"remediators": [{"autoScalingGroup": {}}]}`,
+			contains:  "remediators[0].autoScalingGroup",
+		},
+		{
+			namespace: "alpha",
+			spec:      `{"remediators": [{"webhook": {"url": "https://example.com/scale"}}]}`,
+			contains:  "remediators[0]: Strategies outside of kube-system",
+		},
+		{
+			namespace: "kube-system",
+			spec:      `{"remediators": [{"webhook": {"url": "https://example.com/scale"}}]}`,
+		},
+	}
+
+	for i, test := range tests {
+		rs, err := strategyFromScalingStrategy(makeScalingStrategy(test.namespace, "test", test.spec), "kube-system")
+		if test.contains != "" {
+			if err == nil || !strings.Contains(err.Error(), test.contains) {
+				t.Errorf("Test %d: Expected error containing %q. Got %v", i, test.contains, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: Unexpected error: %v", i, err)
+			continue
+		}
+		if rs.Name != test.namespace+"/test" {
+			t.Errorf("Test %d: Expected name %s/test. Got %s", i, test.namespace, rs.Name)
+		}
+		if test.namespaces == nil {
+			if rs.Namespaces != nil {
+				t.Errorf("Test %d: Expected no namespace condition. Got %v", i, rs.Namespaces.Namespaces)
+			}
+			continue
+		}
+		if rs.Namespaces == nil || strings.Join(rs.Namespaces.Namespaces, ",") != strings.Join(test.namespaces, ",") {
+			t.Errorf("Test %d: Expected namespaces %v. Got %v", i, test.namespaces, rs.Namespaces)
+		}
+	}
+}
+
+func TestScalingStrategyRestrictsGroups(t *testing.T) {
+	client, err := raws.NewStaticAutoscalingClient([]raws.StaticGroup{
+		{Name: "shared", Tags: map[string]string{"team": "alpha"}},
+		{Name: "alpha-workers", Tags: map[string]string{"team": "alpha", raws.ScalerNamespacesTagKey: "beta, alpha"}},
+		{Name: "beta-workers", Tags: map[string]string{raws.ScalerNamespacesTagKey: "beta"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error creating client. %v", err)
+	}
+
+	spec := `{"remediators": [{"autoScalingGroup": {"names": ["shared", "beta-workers"], "tags": {"team": "alpha"}}}]}`
+	tests := []struct {
+		namespace string
+		expected  []string
+	}{
+		{
+			namespace: "alpha",
+			expected:  []string{"alpha-workers"},
+		},
+		{
+			namespace: "kube-system",
+			expected:  []string{"alpha-workers", "beta-workers", "shared"},
+		},
+	}
+
+	for i, test := range tests {
+		rs, err := strategyFromScalingStrategy(makeScalingStrategy(test.namespace, "test", spec), "kube-system")
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error: %v", i, err)
+		}
+		rs.Remediators[0].(*raws.ASGRemediator).SetClient(client)
+
+		groups, err := rs.ResolveGroups()
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error resolving groups: %v", i, err)
+		}
+		sort.Strings(groups)
+		if strings.Join(groups, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("Test %d: Expected groups %v. Got %v", i, test.expected, groups)
+		}
+	}
+}
+
+func TestScalingStrategyDecoder(t *testing.T) {
+	stream := `{"type": "ADDED", "object": {"metadata": {"namespace": "alpha", "name": "first"}, "spec": {"dryRun": true}}}
+{"type": "MODIFIED", "object": {"metadata": {"namespace": "alpha", "name": "first"}, "status": {"lastMatchCount": 3}}}`
+	body := ioutil.NopCloser(strings.NewReader(stream))
+	decoder := &scalingStrategyDecoder{stream: body, decoder: json.NewDecoder(body)}
+
+	eventType, obj, err := decoder.Decode()
+	if err != nil || eventType != watch.Added {
+		t.Fatalf("Expected an added event. Got %s %v", eventType, err)
+	}
+	if s := obj.(*ScalingStrategy); s.Name != "first" || s.Spec["dryRun"] != true {
+		t.Errorf("Unexpected object decoded: %v", s)
+	}
+
+	eventType, obj, err = decoder.Decode()
+	if err != nil || eventType != watch.Modified {
+		t.Fatalf("Expected a modified event. Got %s %v", eventType, err)
+	}
+	if s := obj.(*ScalingStrategy); s.Status.LastMatchCount != 3 {
+		t.Errorf("Expected a match count of 3. Got %d", s.Status.LastMatchCount)
+	}
+
+	if _, _, err = decoder.Decode(); err == nil {
+		t.Error("Expected an error at the end of the stream")
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+)
+
+const (
+	//FailedSchedulingReason is the event reason used by the scheduler when a pod cannot be placed
+	FailedSchedulingReason = "FailedScheduling"
+	//InsufficientResources indicates the scheduler found nodes but none had enough free capacity
+	InsufficientResources = "InsufficientResources"
+	//ConstraintMismatch indicates the pod's constraints (selectors, taints, ports, volumes) could not be met
+	ConstraintMismatch = "ConstraintMismatch"
+	//UnrecognizedScheduleIssue indicates the scheduler explained the failure with a message not known to be about capacity,
+	//such as unbound volume claims or pod affinity
+	UnrecognizedScheduleIssue = "UnrecognizedIssue"
+)
+
+//capacityMessages are scheduler message fragments that can be resolved by adding nodes
+var capacityMessages = []string{
+	"PodExceedsFreeCPU",
+	"PodExceedsFreeMemory",
+	"PodExceedsMaxPodNumber",
+	//Insufficient is followed by the resource, including extended resources such as nvidia.com/gpu
+	"Insufficient ",
+	"Too many pods",
+}
+
+//constraintMessages are scheduler message fragments that adding more of the same nodes will not fix
+var constraintMessages = []string{
+	"MatchNodeSelector",
+	"node selector",
+	"PodToleratesNodeTaints",
+	"taint",
+	"PodFitsHostPorts",
+	"free ports",
+	"NoDiskConflict",
+	"NoVolumeZoneConflict",
+	"HostName",
+	"CheckNodeLabelPresence",
+}
+
+//ScheduleFailure is the most recent scheduler explanation for why a pod is not scheduled
+type ScheduleFailure struct {
+	Reason  string
+	Message string
+	Seen    time.Time
+}
+
+//classifySchedulingMessage maps a scheduler FailedScheduling message to a failure reason.
+//Capacity failures win over constraint failures: if any node only lacked free resources,
+//adding more nodes of that kind may allow the pod to schedule.
+func classifySchedulingMessage(message string) string {
+	if strings.Contains(message, "no nodes available") {
+		return NodeNodesAvailable
+	}
+
+	for _, m := range capacityMessages {
+		if strings.Contains(message, m) {
+			return InsufficientResources
+		}
+	}
+
+	for _, m := range constraintMessages {
+		if strings.Contains(message, m) {
+			return ConstraintMismatch
+		}
+	}
+
+	return UnrecognizedScheduleIssue
+}
+
+//isRemediableReason returns true if scaling out may resolve the failure reason.
+//Pods without a scheduler event yet are considered remediable to avoid missing scheduler events.
+//Pods with a message that is not recognized are reported rather than guessed at
+func isRemediableReason(reason string) bool {
+	switch reason {
+	case ConstraintMismatch, UnrecognizedScheduleIssue:
+		return false
+	default:
+		return true
+	}
+}
+
+//podKeyForEvent returns the pod key an event refers to, or false if the event is not a scheduling failure for a pod
+func podKeyForEvent(e *api.Event) (string, bool) {
+	if e.Reason != FailedSchedulingReason || e.InvolvedObject.Kind != "Pod" {
+		return "", false
+	}
+
+	return e.InvolvedObject.Namespace + "/" + e.InvolvedObject.Name, true
+}
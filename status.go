@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	rapi "github.com/jmccarty3/awsScaler/api"
+	"github.com/jmccarty3/awsScaler/api/remediation"
+)
+
+//PodStatus describes a pod failing to schedule
+type PodStatus struct {
+	Key          string    `json:"key"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	Remediations int       `json:"remediations"`
+	Strategy     string    `json:"strategy,omitempty"`
+	Reason       string    `json:"reason"`
+	Message      string    `json:"message,omitempty"`
+	GivenUp      bool      `json:"givenUp"`
+}
+
+//GroupChange describes the capacity change requested from a group
+type GroupChange struct {
+	Group     string `json:"group"`
+	Before    int64  `json:"before"`
+	After     int64  `json:"after"`
+	Instances int    `json:"instances"`
+}
+
+//TooLargePods lists pods that do not fit on a single instance of a group
+type TooLargePods struct {
+	Group        string   `json:"group"`
+	InstanceType string   `json:"instanceType"`
+	Pods         []string `json:"pods"`
+}
+
+//StrategyRun describes a strategy remediating pods during a cycle
+type StrategyRun struct {
+	Strategy   string         `json:"strategy"`
+	Pods       []string       `json:"pods"`
+	Requested  rapi.Resources `json:"requested"`
+	Unresolved rapi.Resources `json:"unresolved"`
+	DryRun     bool           `json:"dryRun"`
+	Groups     []GroupChange  `json:"groups"`
+	TooLarge   []TooLargePods `json:"tooLarge,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+//CycleStatus describes a remediation cycle
+type CycleStatus struct {
+	Started    time.Time `json:"started"`
+	Remediable int       `json:"remediable"`
+	Unmatched  int       `json:"unmatched"`
+	//Matched counts the remediable pods matched by each strategy
+	Matched map[string]int `json:"matched"`
+	Runs    []StrategyRun  `json:"runs"`
+}
+
+//StrategyGroups lists the groups a strategy currently resolves to
+type StrategyGroups struct {
+	Strategy string   `json:"strategy"`
+	Groups   []string `json:"groups"`
+	Error    string   `json:"error,omitempty"`
+}
+
+//Status is served by /status
+type Status struct {
+	FailingPods []PodStatus      `json:"failingPods"`
+	Cycles      []CycleStatus    `json:"cycles"`
+	Strategies  []StrategyGroups `json:"strategies"`
+	//StrategiesResolved is when the strategies' groups were resolved. Nil until the first remediation cycle
+	StrategiesResolved *time.Time `json:"strategiesResolved,omitempty"`
+}
+
+//newStrategyRun describes the outcome of a remediation request
+func newStrategyRun(label string, requested, unresolved *rapi.Resources, req *remediation.Request, err error) StrategyRun {
+	run := StrategyRun{
+		Strategy:   label,
+		Pods:       req.Pods,
+		Requested:  *requested.Copy(),
+		Unresolved: *unresolved.Copy(),
+		DryRun:     req.DryRun,
+		Groups:     make([]GroupChange, len(req.ScaleUps)),
+	}
+	for i, scaleUp := range req.ScaleUps {
+		run.Groups[i] = GroupChange{
+			Group:     scaleUp.Group,
+			Before:    scaleUp.FromSize,
+			After:     scaleUp.ToSize,
+			Instances: scaleUp.Instances,
+		}
+	}
+	for _, tooLarge := range req.TooLarge {
+		run.TooLarge = append(run.TooLarge, TooLargePods{Group: tooLarge.Group, InstanceType: tooLarge.InstanceType, Pods: tooLarge.Pods})
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	return run
+}
+
+//cycleHistory holds the most recent remediation cycles
+type cycleHistory struct {
+	size   int
+	cycles []CycleStatus
+	lock   sync.Mutex
+}
+
+func newCycleHistory(size int) *cycleHistory {
+	return &cycleHistory{size: size}
+}
+
+//add records a cycle, dropping the oldest once full
+func (h *cycleHistory) add(cycle CycleStatus) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.cycles = append(h.cycles, cycle)
+	if len(h.cycles) > h.size {
+		h.cycles = h.cycles[len(h.cycles)-h.size:]
+	}
+}
+
+//list returns the recorded cycles, newest first
+func (h *cycleHistory) list() []CycleStatus {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	cycles := make([]CycleStatus, len(h.cycles))
+	for i, cycle := range h.cycles {
+		cycles[len(h.cycles)-1-i] = cycle
+	}
+	return cycles
+}
+
+//podStatuses returns the failing pods ordered by key
+func podStatuses(pods map[string]FailedPod) []PodStatus {
+	statuses := make([]PodStatus, 0, len(pods))
+	for key, p := range pods {
+		statuses = append(statuses, PodStatus{
+			Key:          key,
+			FirstSeen:    p.FirstSeen,
+			Remediations: p.Remediations,
+			Strategy:     p.Strategy,
+			Reason:       p.Failure.Reason,
+			Message:      p.Failure.Message,
+			GivenUp:      !p.GaveUpAt.IsZero(),
+		})
+	}
+	sort.Sort(byPodKey(statuses))
+	return statuses
+}
+
+type byPodKey []PodStatus
+
+func (a byPodKey) Len() int           { return len(a) }
+func (a byPodKey) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byPodKey) Less(i, j int) bool { return a[i].Key < a[j].Key }
+
+//strategyGroupsTTL is how long the groups resolved for /status are kept while the strategies do not change
+const strategyGroupsTTL = 10 * time.Minute
+
+//resolvedGroups holds the groups the strategies resolved to during a recent remediation cycle.
+//Requests to /status are served from it so polling does not use up the AWS API limits remediation relies on
+type resolvedGroups struct {
+	strategies []StrategyGroups
+	resolved   time.Time
+	//stale is set when the strategies change
+	stale bool
+	lock  sync.Mutex
+}
+
+//set replaces the resolved groups
+func (r *resolvedGroups) set(strategies []StrategyGroups, resolved time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.strategies, r.resolved, r.stale = strategies, resolved, false
+}
+
+//invalidate makes the next cycle resolve the groups again
+func (r *resolvedGroups) invalidate() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.stale = true
+}
+
+//due returns true if the groups were never resolved, the strategies changed or the groups are older than ttl
+func (r *resolvedGroups) due(now time.Time, ttl time.Duration) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.stale || r.resolved.IsZero() || now.Sub(r.resolved) >= ttl
+}
+
+//get returns the resolved groups and when they were resolved
+func (r *resolvedGroups) get() ([]StrategyGroups, time.Time) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.strategies, r.resolved
+}
+
+//resolveStrategyGroups resolves the groups of the strategies used in the current cycle and records them for /status.
+//Groups resolved within strategyGroupsTTL for the same strategies are kept
+func (k *kubeDataProvider) resolveStrategyGroups(now time.Time) {
+	if !k.resolvedGroups.due(now, strategyGroupsTTL) {
+		return
+	}
+
+	groups := make([]StrategyGroups, len(k.strategies))
+	for i, s := range k.strategies {
+		groups[i].Strategy = strategyLabel(i, s)
+		resolved, err := s.ResolveGroups()
+		groups[i].Groups = resolved
+		if err != nil {
+			groups[i].Error = err.Error()
+		}
+	}
+	k.resolvedGroups.set(groups, now)
+}
+
+//ServeStatus implements /status
+func (k *kubeDataProvider) ServeStatus(w http.ResponseWriter, r *http.Request) {
+	status := Status{
+		FailingPods: podStatuses(k.failingPods.snapshot()),
+		Cycles:      k.history.list(),
+	}
+	var resolved time.Time
+	if status.Strategies, resolved = k.resolvedGroups.get(); !resolved.IsZero() {
+		status.StrategiesResolved = &resolved
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		glog.Warningf("Unable to write status: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/jmccarty3/awsScaler/api/remediation"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+)
+
+//StatusConfigMapKey is the key holding the summary within the status ConfigMap
+const StatusConfigMapKey = "status"
+
+//statusConfigMap writes a human readable summary of each remediation cycle to a ConfigMap
+type statusConfigMap struct {
+	client    *kclient.Client
+	namespace string
+	name      string
+	//last is the summary last written
+	last string
+	//idle is true if the summary last written had no failing pods or capacity in flight
+	idle bool
+}
+
+func newStatusConfigMap(client *kclient.Client, namespace, name string) *statusConfigMap {
+	return &statusConfigMap{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+//changed returns true if the summary differs from the one last written
+func (s *statusConfigMap) changed(summary string) bool {
+	return summary != s.last
+}
+
+//write creates the ConfigMap or replaces its summary
+func (s *statusConfigMap) write(summary string) error {
+	configMaps := s.client.ConfigMaps(s.namespace)
+	cm, err := configMaps.Get(s.name)
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(&api.ConfigMap{
+			ObjectMeta: api.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.name,
+			},
+			Data: map[string]string{StatusConfigMapKey: summary},
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[StatusConfigMapKey] = summary
+	_, err = configMaps.Update(cm)
+	return err
+}
+
+//strategySummary describes a strategy and the groups it currently resolves to
+type strategySummary struct {
+	Label   string
+	Waiting int
+	Groups  []remediation.GroupDescription
+	Err     error
+}
+
+//summarizeCycle renders the state of the scaler after a remediation cycle for on-call engineers.
+//The time is left out so cycles that changed nothing render the same summary
+func summarizeCycle(cycle CycleStatus, strategies []strategySummary, pods map[string]FailedPod, inFlight []*remediation.InFlight) string {
+	givenUp := 0
+	for _, p := range pods {
+		if !p.GaveUpAt.IsZero() {
+			givenUp++
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Failing pods: %d (%d remediable, %d given up, %d without a matching strategy)\n", len(pods), cycle.Remediable, givenUp, cycle.Unmatched)
+
+	for _, s := range strategies {
+		fmt.Fprintf(&buf, "\nStrategy %s: %d pods waiting\n", s.Label, s.Waiting)
+		if s.Err != nil {
+			fmt.Fprintf(&buf, "  Error finding groups: %v\n", s.Err)
+		}
+		for _, g := range s.Groups {
+			fmt.Fprintf(&buf, "  %s: desired %d, max %d, instances %d", g.Name, g.DesiredCapacity, g.MaxSize, g.Instances)
+			if g.Activity != "" {
+				fmt.Fprintf(&buf, ", last activity %s", g.Activity)
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	if len(inFlight) > 0 {
+		sort.Sort(byRequested(inFlight))
+		buf.WriteString("\nIn flight:\n")
+		for _, e := range inFlight {
+			fmt.Fprintf(&buf, "  %s: +%d instances requested %s for %d pods\n", e.Group, e.Instances, e.Requested.Format(time.RFC3339), len(e.Pods))
+		}
+	}
+	return buf.String()
+}
+
+type byRequested []*remediation.InFlight
+
+func (a byRequested) Len() int           { return len(a) }
+func (a byRequested) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byRequested) Less(i, j int) bool { return a[i].Requested.Before(a[j].Requested) }
+
+//writeStatusConfigMap summarizes the cycle into the status ConfigMap if one is configured and this replica is the leader.
+//The ConfigMap is only updated when the summary changes. Groups are not described again while the scaler stays idle
+func (k *kubeDataProvider) writeStatusConfigMap(cycle CycleStatus) {
+	if k.statusConfigMap == nil || !k.leader.IsLeader() {
+		return
+	}
+
+	pods, inFlight := k.failingPods.snapshot(), k.inFlight.Entries()
+	idle := len(pods) == 0 && len(inFlight) == 0
+	if idle && k.statusConfigMap.idle {
+		return
+	}
+
+	summaries := make([]strategySummary, len(k.strategies))
+	for i, s := range k.strategies {
+		label := strategyLabel(i, s)
+		summaries[i] = strategySummary{Label: label, Waiting: cycle.Matched[label]}
+		summaries[i].Groups, summaries[i].Err = s.DescribeGroups()
+	}
+
+	summary := summarizeCycle(cycle, summaries, pods, inFlight)
+	if !k.statusConfigMap.changed(summary) {
+		return
+	}
+	if err := k.statusConfigMap.write(fmt.Sprintf("Updated: %s\n%s", cycle.Started.Format(time.RFC3339), summary)); err != nil {
+		glog.Warningf("Unable to write status to config map %s/%s: %v", k.statusConfigMap.namespace, k.statusConfigMap.name, err)
+		return
+	}
+	k.statusConfigMap.last, k.statusConfigMap.idle = summary, idle
+}
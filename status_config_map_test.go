@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmccarty3/awsScaler/api/remediation"
+)
+
+func TestSummarizeCycle(t *testing.T) {
+	now := time.Now()
+	cycle := CycleStatus{Started: now, Remediable: 3, Unmatched: 1}
+	strategies := []strategySummary{
+		{
+			Label:   "0",
+			Waiting: 2,
+			Groups: []remediation.GroupDescription{
+				{Name: "asg-foo", DesiredCapacity: 3, MaxSize: 10, Instances: 2, Activity: "InProgress: Launching a new EC2 instance (50%)"},
+				{Name: "asg-bar", DesiredCapacity: 1, MaxSize: 1, Instances: 1},
+			},
+		},
+		{
+			Label: "alpha/workers",
+			Err:   errors.New("Not all self tags found"),
+		},
+	}
+	pods := map[string]FailedPod{
+		"ns/a": {},
+		"ns/b": {GaveUpAt: now},
+	}
+	inFlight := []*remediation.InFlight{
+		{Group: "asg-foo", Instances: 1, Pods: []string{"ns/a"}, Requested: now},
+	}
+
+	summary := summarizeCycle(cycle, strategies, pods, inFlight)
+	expected := []string{
+		"Failing pods: 2 (3 remediable, 1 given up, 1 without a matching strategy)",
+		"Strategy 0: 2 pods waiting",
+		"  asg-foo: desired 3, max 10, instances 2, last activity InProgress: Launching a new EC2 instance (50%)\n",
+		"  asg-bar: desired 1, max 1, instances 1\n",
+		"Strategy alpha/workers: 0 pods waiting\n  Error finding groups: Not all self tags found",
+		"In flight:\n  asg-foo: +1 instances requested",
+	}
+	for _, e := range expected {
+		if !strings.Contains(summary, e) {
+			t.Errorf("Expected summary to contain %q. Summary:\n%s", e, summary)
+		}
+	}
+}
+
+func TestStatusConfigMapSkipsUnchangedWrites(t *testing.T) {
+	//The ConfigMap has no client so any write would panic
+	k := &kubeDataProvider{
+		failingPods:     NewFailedPods(1, 0),
+		inFlight:        remediation.NewInFlightLedger(time.Hour),
+		statusConfigMap: &statusConfigMap{idle: true},
+	}
+	k.writeStatusConfigMap(CycleStatus{Started: time.Now()})
+
+	summary := summarizeCycle(CycleStatus{}, nil, nil, nil)
+	k.statusConfigMap = &statusConfigMap{last: summary}
+	if k.statusConfigMap.changed(summary) {
+		t.Error("Expected an identical summary to be unchanged")
+	}
+	if !k.statusConfigMap.changed(summary + "\nIn flight:") {
+		t.Error("Expected a different summary to be changed")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rapi "github.com/jmccarty3/awsScaler/api"
+	"github.com/jmccarty3/awsScaler/api/remediation"
+)
+
+func TestCycleHistory(t *testing.T) {
+	history := newCycleHistory(2)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		history.add(CycleStatus{Started: start.Add(time.Duration(i) * time.Minute)})
+	}
+
+	cycles := history.list()
+	if len(cycles) != 2 {
+		t.Fatalf("Expected 2 cycles. Actual %d", len(cycles))
+	}
+	if !cycles[0].Started.Equal(start.Add(2*time.Minute)) || !cycles[1].Started.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected newest cycles first. Actual %v", cycles)
+	}
+}
+
+func TestPodStatuses(t *testing.T) {
+	failed := NewFailedPods(1, 0)
+	failed.setFailure("ns/b", ScheduleFailure{Reason: InsufficientResources, Message: "Insufficient CPU", Seen: time.Now()})
+	failed.addPod("ns/b", makePod("ns", "b", "1"))
+	failed.addPod("ns/a", makePod("ns", "a", "1"))
+	failed.setStrategy([]string{"ns/b"}, "0")
+	failed.incrementRemediations([]string{"ns/b"})
+
+	statuses := podStatuses(failed.snapshot())
+	if len(statuses) != 2 || statuses[0].Key != "ns/a" || statuses[1].Key != "ns/b" {
+		t.Fatalf("Expected pods ordered by key. Actual %v", statuses)
+	}
+	if statuses[0].GivenUp || statuses[0].FirstSeen.IsZero() {
+		t.Errorf("Unexpected status for ns/a: %v", statuses[0])
+	}
+	b := statuses[1]
+	if !b.GivenUp || b.Remediations != 1 || b.Strategy != "0" || b.Reason != InsufficientResources {
+		t.Errorf("Unexpected status for ns/b: %v", b)
+	}
+}
+
+func TestNewStrategyRun(t *testing.T) {
+	req := &remediation.Request{Pods: []string{"ns/a"}}
+	req.RecordScaleUp(remediation.ScaleUp{Group: "foo", FromSize: 2, ToSize: 4, Instances: 2})
+
+	run := newStrategyRun("0", &rapi.Resources{CPU: 4000}, &rapi.EmptyResources, req, errors.New("failed"))
+	if len(run.Groups) != 1 || run.Groups[0].Before != 2 || run.Groups[0].After != 4 {
+		t.Errorf("Unexpected groups: %v", run.Groups)
+	}
+	if run.Requested.CPU != 4000 || run.Error != "failed" || len(run.Pods) != 1 {
+		t.Errorf("Unexpected run: %v", run)
+	}
+
+	//Remediators remove what they provide from the requested resources after the run is recorded
+	requested := (&rapi.Resources{CPU: 1000}).Set(rapi.ResourceGPU, 2)
+	run = newStrategyRun("0", requested, &rapi.EmptyResources, req, nil)
+	requested.Remove(requested.Copy())
+	if run.Requested.Get(rapi.ResourceGPU) != 2 {
+		t.Errorf("Expected the run to keep the 2 GPUs requested. Got %v", run.Requested)
+	}
+}
+
+func TestServeStatusUsesResolvedGroups(t *testing.T) {
+	k := &kubeDataProvider{failingPods: NewFailedPods(1, 0), history: newCycleHistory(1)}
+
+	serve := func() Status {
+		recorder := httptest.NewRecorder()
+		k.ServeStatus(recorder, &http.Request{})
+		var status Status
+		if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+			t.Fatalf("Unable to decode status: %v", err)
+		}
+		return status
+	}
+
+	if status := serve(); status.StrategiesResolved != nil || len(status.Strategies) != 0 {
+		t.Errorf("Expected no strategies before the first cycle. Got %v", status)
+	}
+
+	resolved := time.Now().Truncate(time.Second)
+	k.resolvedGroups.set([]StrategyGroups{{Strategy: "0", Groups: []string{"foo"}}}, resolved)
+	status := serve()
+	if status.StrategiesResolved == nil || !status.StrategiesResolved.Equal(resolved) {
+		t.Errorf("Expected strategies resolved at %v. Got %v", resolved, status.StrategiesResolved)
+	}
+	if len(status.Strategies) != 1 || status.Strategies[0].Groups[0] != "foo" {
+		t.Errorf("Expected the groups resolved during the last cycle. Got %v", status.Strategies)
+	}
+}
+
+func TestResolvedGroupsDue(t *testing.T) {
+	var groups resolvedGroups
+	now := time.Now()
+	if !groups.due(now, time.Minute) {
+		t.Error("Expected groups never resolved to be due")
+	}
+
+	groups.set(nil, now)
+	if groups.due(now.Add(30*time.Second), time.Minute) {
+		t.Error("Expected groups resolved within the TTL to be kept")
+	}
+	if !groups.due(now.Add(time.Minute), time.Minute) {
+		t.Error("Expected groups older than the TTL to be due")
+	}
+
+	groups.invalidate()
+	if !groups.due(now, time.Minute) {
+		t.Error("Expected groups to be due after the strategies changed")
+	}
+}
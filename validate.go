@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+//runValidate checks a configuration file and reports the first problem found
+func runValidate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to the configuration file")
+	flags.Parse(args)
+
+	if *configPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: awsScaler validate --config config.yaml")
+		return 1
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s is invalid: %v\n", *configPath, err)
+		return 1
+	}
+
+	fmt.Printf("%s is valid. %d strategies, %d instance types from the instance catalog\n", *configPath, len(config.Strategies), len(config.InstanceTypes))
+	return 0
+}